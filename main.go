@@ -1,12 +1,27 @@
 package main
 
 import (
-	"github.com/hashicorp/terraform-plugin-sdk/v2/plugin"
+	"context"
+	"log"
+	"os"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5/tf5server"
 	"github.com/josajunior81/terraform-provider-vcd-object-storage-ext/objectstorage"
 )
 
+const providerAddress = "registry.terraform.io/josajunior81/vcd-object-storage-ext"
+
 func main() {
-	plugin.Serve(&plugin.ServeOpts{
-		ProviderFunc: objectstorage.Provider,
-	})
+	if len(os.Args) > 1 && os.Args[1] == "generate" {
+		os.Exit(objectstorage.RunGenerate(os.Args[2:]))
+	}
+
+	providerServer, err := objectstorage.ProviderServerFactory(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := tf5server.Serve(providerAddress, providerServer); err != nil {
+		log.Fatal(err)
+	}
 }
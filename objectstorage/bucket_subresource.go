@@ -0,0 +1,152 @@
+package objectstorage
+
+import (
+	"context"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/josajunior81/terraform-provider-vcd-object-storage-ext/pkg"
+)
+
+// bucketSubresourceOps are the calls specific to a bucket sub-resource, a
+// resource managing one configuration of a bucket, such as its policy, and
+// identified by the bucket name. M is the model of the resource.
+type bucketSubresourceOps[M any] interface {
+	// put replaces the configuration of the bucket with the planned one.
+	// Errors converting the plan are added to diags.
+	put(ctx context.Context, plan M, diags *diag.Diagnostics) error
+	// read sets the live configuration of the bucket on model and reports
+	// whether the bucket has one.
+	read(ctx context.Context, model *M) (bool, error)
+	// remove removes the configuration of bucket.
+	remove(ctx context.Context, bucket string) error
+}
+
+// bucketSubresource implements Configure, the CRUD and the import of a bucket
+// sub-resource around its ops. Resources embed it and add their Metadata and
+// Schema.
+type bucketSubresource[M any] struct {
+	storage pkg.ObjectStorage
+	// name is the configuration named in diagnostics, e.g. "policy".
+	name string
+	ops  bucketSubresourceOps[M]
+}
+
+// bucketSubresourceAttributes adds the id and bucket attributes shared by the
+// bucket sub-resources to attributes.
+func bucketSubresourceAttributes(attributes map[string]schema.Attribute) map[string]schema.Attribute {
+	attributes["id"] = schema.StringAttribute{
+		Computed:    true,
+		Description: "The bucket name.",
+		PlanModifiers: []planmodifier.String{
+			stringplanmodifier.UseStateForUnknown(),
+		},
+	}
+	attributes["bucket"] = schema.StringAttribute{
+		Required:    true,
+		Description: "The bucket name.",
+		PlanModifiers: []planmodifier.String{
+			stringplanmodifier.RequiresReplace(),
+		},
+	}
+	return attributes
+}
+
+func (r *bucketSubresource[M]) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.storage = storageFromProviderData(req.ProviderData, &resp.Diagnostics)
+}
+
+func (r *bucketSubresource[M]) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	r.apply(ctx, req.Plan, &resp.State, &resp.Diagnostics)
+}
+
+func (r *bucketSubresource[M]) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state M
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	found, err := r.ops.read(ctx, &state)
+	if pkg.IsNotFound(err) || err == nil && !found {
+		log.Printf("[WARN] Bucket %s has no %s, removing from state", r.bucket(ctx, req.State, &resp.Diagnostics), r.name)
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		addErrorDiagnostic(&resp.Diagnostics, "Error reading bucket "+r.name, err)
+		return
+	}
+
+	r.setState(ctx, state, &resp.State, &resp.Diagnostics)
+}
+
+func (r *bucketSubresource[M]) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	r.apply(ctx, req.Plan, &resp.State, &resp.Diagnostics)
+}
+
+func (r *bucketSubresource[M]) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	bucket := r.bucket(ctx, req.State, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.ops.remove(ctx, bucket); err != nil && !pkg.IsNotFound(err) {
+		addErrorDiagnostic(&resp.Diagnostics, "Error deleting bucket "+r.name, err)
+	}
+}
+
+// ImportState imports the configuration of a bucket by the bucket name.
+func (r *bucketSubresource[M]) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("bucket"), req, resp)
+}
+
+// apply puts the planned configuration and stores the configuration read back
+// from the bucket in state.
+func (r *bucketSubresource[M]) apply(ctx context.Context, plan tfsdk.Plan, state *tfsdk.State, diags *diag.Diagnostics) {
+	var model M
+	diags.Append(plan.Get(ctx, &model)...)
+	if diags.HasError() {
+		return
+	}
+
+	err := r.ops.put(ctx, model, diags)
+	if diags.HasError() {
+		return
+	}
+	if err != nil {
+		addErrorDiagnostic(diags, "Error setting bucket "+r.name, err)
+		return
+	}
+
+	if _, err := r.ops.read(ctx, &model); err != nil {
+		addErrorDiagnostic(diags, "Error reading bucket "+r.name, err)
+		return
+	}
+
+	r.setState(ctx, model, state, diags)
+}
+
+// setState stores model in state with the bucket name as id.
+func (r *bucketSubresource[M]) setState(ctx context.Context, model M, state *tfsdk.State, diags *diag.Diagnostics) {
+	diags.Append(state.Set(ctx, &model)...)
+	if diags.HasError() {
+		return
+	}
+
+	bucket := r.bucket(ctx, *state, diags)
+	diags.Append(state.SetAttribute(ctx, path.Root("id"), bucket)...)
+}
+
+// bucket returns the bucket attribute of state.
+func (r *bucketSubresource[M]) bucket(ctx context.Context, state tfsdk.State, diags *diag.Diagnostics) string {
+	var bucket string
+	diags.Append(state.GetAttribute(ctx, path.Root("bucket"), &bucket)...)
+	return bucket
+}
@@ -1,10 +1,159 @@
 package objectstorage
 
-import "github.com/josajunior81/terraform-provider-vcd-object-storage-ext/pkg"
+import (
+	"errors"
+	"fmt"
+	"sync"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/josajunior81/terraform-provider-vcd-object-storage-ext/pkg"
+)
+
+// Config holds the provider settings. It is only filled by providerConfig,
+// the framework provider is configured through the SDKv2 one.
 type Config struct {
-	AccessKey string `json:"access_key"`
-	SecretKey string `json:"secret_key"`
-	Url       string `json:"s3_url"`
-	S3Client  pkg.S3Client
+	AccessKey    string `json:"access_key"`
+	SecretKey    string `json:"secret_key"`
+	SessionToken string `json:"session_token"`
+	Url          string `json:"s3_url"`
+	Region       string `json:"region"`
+	Org          string `json:"org"`
+	ApiToken     string `json:"api_token"`
+	VcdUrl       string `json:"vcd_url"`
+
+	Insecure      bool   `json:"insecure"`
+	CaFile        string `json:"ca_file"`
+	CaPem         string `json:"ca_pem"`
+	MinTlsVersion string `json:"min_tls_version"`
+
+	Protocol   string `json:"protocol"`
+	Addressing string `json:"addressing"`
+
+	RetryPolicy pkg.RetryPolicy     `json:"-"`
+	Multipart   pkg.MultipartConfig `json:"-"`
+}
+
+// Client builds the Object Storage client described by the configuration.
+func (c Config) Client() (pkg.ObjectStorage, diag.Diagnostics) {
+	var providerDiag = diag.Diagnostics{}
+
+	if c.Url == "" {
+		return nil, append(providerDiag, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Missing s3_url",
+			Detail:   "Set s3_url or the S3_URL environment variable to the Object Storage url.",
+		})
+	}
+
+	tlsConfig, err := pkg.NewTLSConfig(c.Insecure, c.CaFile, c.CaPem, c.MinTlsVersion)
+	if err != nil {
+		return nil, append(providerDiag, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Invalid TLS configuration",
+			Detail:   fmt.Sprintf("Check ca_file, ca_pem and min_tls_version: %v", err),
+		})
+	}
+
+	opts := []pkg.S3ClientOption{
+		pkg.WithRetryPolicy(c.RetryPolicy),
+		pkg.WithMultipartConfig(c.Multipart),
+		pkg.WithTLSConfig(tlsConfig),
+		pkg.WithProtocol(pkg.Protocol(c.Protocol), pkg.Addressing(c.Addressing)),
+	}
+
+	switch {
+	case c.ApiToken != "" && c.AccessKey != "":
+		return nil, append(providerDiag, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Conflicting credentials",
+			Detail:   "Set either api_token, to authenticate through VCD, or access_key and secret_key, to sign requests with S3 user keys.",
+		})
+	case c.AccessKey != "":
+		if c.SecretKey == "" {
+			return nil, append(providerDiag, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Missing secret_key",
+				Detail:   "secret_key is required when access_key is set.",
+			})
+		}
+		return pkg.NewS3ClientWithKeys(c.Url, c.Region, c.AccessKey, c.SecretKey, c.SessionToken, opts...), providerDiag
+	case c.ApiToken != "":
+		if c.Org == "" || c.VcdUrl == "" {
+			return nil, append(providerDiag, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Missing org or vcd_url",
+				Detail:   "org and vcd_url are required when authenticating with api_token.",
+			})
+		}
+	default:
+		return nil, append(providerDiag, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Missing credentials",
+			Detail:   "Set either api_token, org and vcd_url, or access_key and secret_key.",
+		})
+	}
+
+	s3client, err := pkg.NewS3Client(c.Url, c.Region, c.ApiToken, c.Org, c.VcdUrl, opts...)
+	if err != nil {
+		return nil, append(providerDiag, clientDiagnostic(err))
+	}
+
+	return s3client, providerDiag
+}
+
+// clientDiagnostic explains why the Object Storage client could not be created.
+func clientDiagnostic(err error) diag.Diagnostic {
+	summary := "Unable to create the Object Storage client"
+	detail := err.Error()
+
+	switch {
+	case errors.Is(err, pkg.ErrInvalidUrl):
+		summary = "Invalid vcd_url"
+		detail = fmt.Sprintf("vcd_url must be an absolute url such as https://vcd.example.com: %v", err)
+	case errors.Is(err, pkg.ErrAuthentication):
+		summary = "Unable to authenticate with VCD"
+		detail = fmt.Sprintf("Check that api_token is valid and not expired, that org is the organization it was issued for and that vcd_url is reachable: %v", err)
+	}
+
+	return diag.Diagnostic{
+		Severity: diag.Error,
+		Summary:  summary,
+		Detail:   detail,
+	}
+}
+
+// clientCache hands the same client to the SDKv2 and the framework halves of
+// the muxed provider, which are configured with the same settings, so that
+// VCD is only asked for a token once.
+type clientCache struct {
+	mu      sync.Mutex
+	clients map[string]pkg.ObjectStorage
+}
+
+func newClientCache() *clientCache {
+	return &clientCache{clients: map[string]pkg.ObjectStorage{}}
+}
+
+// client returns the client built for an identical configuration, or builds
+// it. A nil cache always builds a new client.
+func (c *clientCache) client(config Config) (pkg.ObjectStorage, diag.Diagnostics) {
+	if c == nil {
+		return config.Client()
+	}
+
+	key := fmt.Sprintf("%#v", config)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if client, ok := c.clients[key]; ok {
+		return client, nil
+	}
+
+	client, diags := config.Client()
+	if !diags.HasError() {
+		c.clients[key] = client
+	}
+
+	return client, diags
 }
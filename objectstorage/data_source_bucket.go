@@ -1,47 +1,84 @@
 package objectstorage
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/josajunior81/terraform-provider-vcd-object-storage-ext/pkg"
 )
 
-func dataSourceBucket() *schema.Resource {
-	return &schema.Resource{
-		Read: dataSourceBucketRead,
-		Schema: map[string]*schema.Schema{
-			"name": {
-				Type:     schema.TypeString,
+type bucketDataSource struct {
+	storage pkg.ObjectStorage
+}
+
+var _ datasource.DataSourceWithConfigure = (*bucketDataSource)(nil)
+
+type bucketDataSourceModel struct {
+	Id   types.String `tfsdk:"id"`
+	Name types.String `tfsdk:"name"`
+}
+
+func newBucketDataSource() datasource.DataSource {
+	return &bucketDataSource{}
+}
+
+func (d *bucketDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_bucket"
+}
+
+func (d *bucketDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:    true,
+				Description: "The ID of this data source, the bucket name.",
+			},
+			"name": schema.StringAttribute{
 				Required: true,
 			},
 		},
 	}
 }
 
-func dataSourceBucketRead(d *schema.ResourceData, meta interface{}) error {
-	s3 := meta.(pkg.S3Client)
-	name := d.Get("name").(string)
+func (d *bucketDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	d.storage = storageFromProviderData(req.ProviderData, &resp.Diagnostics)
+}
 
-	bucket, err := s3.GetBucket(name)
+func (d *bucketDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data bucketDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	name := data.Name.ValueString()
+
+	bucket, err := d.storage.GetBucket(ctx, name)
 	if err != nil {
 		log.Printf("Error reading Bucket: %s", err)
-		return err
+		if pkg.IsNotFound(err) {
+			resp.Diagnostics.AddError("Bucket not found", fmt.Sprintf("bucket %q not found: %v", name, err))
+			return
+		}
+		addErrorDiagnostic(&resp.Diagnostics, "Error reading bucket", err)
+		return
 	}
 
 	var jsonBucket pkg.Bucket
 
 	if err := json.Unmarshal([]byte(bucket), &jsonBucket); err != nil {
 		log.Printf("Error Unmarshal Bucket: %s", err)
-		return err
+		resp.Diagnostics.AddError("Error reading bucket", err.Error())
+		return
 	}
-	log.Printf("jsonBucket %v", jsonBucket)
 
-	if err := d.Set("name", jsonBucket.Name); err != nil {
-		log.Printf("Error d.Set(name, jsonBucket.Name) Bucket: %s", err)
-		return err
-	}
+	data.Name = types.StringValue(jsonBucket.Name)
+	data.Id = types.StringValue(jsonBucket.Name)
 
-	return nil
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
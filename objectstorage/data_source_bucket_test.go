@@ -0,0 +1,51 @@
+package objectstorage
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccDataSourceBucket_basic(t *testing.T) {
+	server := testAccServer(t)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderConfig(server) + `
+resource "vcd-object-storage-ext_bucket" "test" {
+  name = "acc-data-source"
+}
+
+data "vcd-object-storage-ext_bucket" "test" {
+  name = vcd-object-storage-ext_bucket.test.name
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.vcd-object-storage-ext_bucket.test", "id", "acc-data-source"),
+					resource.TestCheckResourceAttr("data.vcd-object-storage-ext_bucket.test", "name", "acc-data-source"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccDataSourceBucket_notFound(t *testing.T) {
+	server := testAccServer(t)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderConfig(server) + `
+data "vcd-object-storage-ext_bucket" "test" {
+  name = "missing"
+}
+`,
+				ExpectError: regexp.MustCompile(`bucket "missing" not found`),
+			},
+		},
+	})
+}
@@ -0,0 +1,428 @@
+package objectstorage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"sort"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/setvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/josajunior81/terraform-provider-vcd-object-storage-ext/pkg"
+)
+
+const (
+	policyEffectAllow = "Allow"
+	policyEffectDeny  = "Deny"
+
+	// policyPrincipalAll is the principal type and identifier of everyone.
+	policyPrincipalAll = "*"
+)
+
+// policyDocumentDataSource assembles bucket policies in HCL. It does not call
+// the Object Storage, so it needs no Configure.
+type policyDocumentDataSource struct{}
+
+var _ datasource.DataSource = (*policyDocumentDataSource)(nil)
+
+type policyDocumentModel struct {
+	Id                      types.String           `tfsdk:"id"`
+	Json                    types.String           `tfsdk:"json"`
+	Version                 types.String           `tfsdk:"version"`
+	PolicyId                types.String           `tfsdk:"policy_id"`
+	SourcePolicyDocuments   []string               `tfsdk:"source_policy_documents"`
+	OverridePolicyDocuments []string               `tfsdk:"override_policy_documents"`
+	Statements              []policyStatementModel `tfsdk:"statement"`
+}
+
+type policyStatementModel struct {
+	Sid           types.String           `tfsdk:"sid"`
+	Effect        types.String           `tfsdk:"effect"`
+	Actions       []string               `tfsdk:"actions"`
+	NotActions    []string               `tfsdk:"not_actions"`
+	Resources     []string               `tfsdk:"resources"`
+	NotResources  []string               `tfsdk:"not_resources"`
+	Principals    []policyPrincipalModel `tfsdk:"principals"`
+	NotPrincipals []policyPrincipalModel `tfsdk:"not_principals"`
+	Conditions    []policyConditionModel `tfsdk:"condition"`
+}
+
+type policyPrincipalModel struct {
+	Type        string   `tfsdk:"type"`
+	Identifiers []string `tfsdk:"identifiers"`
+}
+
+type policyConditionModel struct {
+	Test     string   `tfsdk:"test"`
+	Variable string   `tfsdk:"variable"`
+	Values   []string `tfsdk:"values"`
+}
+
+func newPolicyDocumentDataSource() datasource.DataSource {
+	return &policyDocumentDataSource{}
+}
+
+func (d *policyDocumentDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_policy_document"
+}
+
+func (d *policyDocumentDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	principalsBlock := func(description string) schema.ListNestedBlock {
+		return schema.ListNestedBlock{
+			Description: description,
+			NestedObject: schema.NestedBlockObject{
+				Attributes: map[string]schema.Attribute{
+					"type": schema.StringAttribute{
+						Required:    true,
+						Description: "Type of the principals. Valid Values: AWS | CanonicalUser | *",
+						Validators: []validator.String{
+							stringvalidator.OneOf("AWS", "CanonicalUser", policyPrincipalAll),
+						},
+					},
+					"identifiers": schema.SetAttribute{
+						Required:    true,
+						ElementType: types.StringType,
+						Description: "Identifiers of the principals, e.g. user ARNs or canonical user ids. Must be [\"*\"] for the * type",
+					},
+				},
+			},
+		}
+	}
+
+	resp.Schema = schema.Schema{
+		Description: "Generates a bucket policy document in JSON, to be used with the bucket_policy resource.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:    true,
+				Description: "The ID of this data source, a hash of the document.",
+			},
+			"json": schema.StringAttribute{
+				Computed:    true,
+				Description: "The policy document in JSON.",
+			},
+			"version": schema.StringAttribute{
+				Optional:    true,
+				Description: "Version of the policy language. Valid Values: 2008-10-17 | 2012-10-17. Default 2012-10-17",
+				Validators: []validator.String{
+					stringvalidator.OneOf("2008-10-17", pkg.PolicyVersion),
+				},
+			},
+			"policy_id": schema.StringAttribute{
+				Optional:    true,
+				Description: "Id of the policy.",
+			},
+			"source_policy_documents": schema.ListAttribute{
+				Optional:    true,
+				ElementType: types.StringType,
+				Description: "Policy documents the statements are added to. Statements with the sid of a statement block are replaced by it",
+			},
+			"override_policy_documents": schema.ListAttribute{
+				Optional:    true,
+				ElementType: types.StringType,
+				Description: "Policy documents whose statements replace the statements with the same sid, or are added otherwise. Later documents take precedence",
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"statement": schema.ListNestedBlock{
+				Description: "A policy statement.",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"sid": schema.StringAttribute{
+							Optional:    true,
+							Description: "Identifier of the statement, unique in the document.",
+						},
+						"effect": schema.StringAttribute{
+							Optional:    true,
+							Description: "Whether the statement allows or denies the actions. Valid Values: Allow | Deny. Default Allow",
+							Validators: []validator.String{
+								stringvalidator.OneOf(policyEffectAllow, policyEffectDeny),
+							},
+						},
+						"actions": schema.SetAttribute{
+							Optional:    true,
+							ElementType: types.StringType,
+							Description: "S3 actions the statement applies to, e.g. s3:GetObject. Wildcards are allowed. Conflicts with not_actions",
+							Validators: []validator.Set{
+								setvalidator.ValueStringsAre(policyActionValidator{}),
+								setvalidator.ConflictsWith(path.MatchRelative().AtParent().AtName("not_actions")),
+							},
+						},
+						"not_actions": schema.SetAttribute{
+							Optional:    true,
+							ElementType: types.StringType,
+							Description: "S3 actions the statement does not apply to.",
+							Validators: []validator.Set{
+								setvalidator.ValueStringsAre(policyActionValidator{}),
+							},
+						},
+						"resources": schema.SetAttribute{
+							Optional:    true,
+							ElementType: types.StringType,
+							Description: "ARNs of the buckets and objects the statement applies to, e.g. arn:aws:s3:::my-bucket/*. Conflicts with not_resources",
+							Validators: []validator.Set{
+								setvalidator.ConflictsWith(path.MatchRelative().AtParent().AtName("not_resources")),
+							},
+						},
+						"not_resources": schema.SetAttribute{
+							Optional:    true,
+							ElementType: types.StringType,
+							Description: "ARNs of the buckets and objects the statement does not apply to.",
+						},
+					},
+					Blocks: map[string]schema.Block{
+						"principals":     principalsBlock("Principals the statement applies to. Conflicts with not_principals"),
+						"not_principals": principalsBlock("Principals the statement does not apply to."),
+						"condition": schema.ListNestedBlock{
+							Description: "A condition for the statement to apply.",
+							NestedObject: schema.NestedBlockObject{
+								Attributes: map[string]schema.Attribute{
+									"test": schema.StringAttribute{
+										Required:    true,
+										Description: "Condition operator, e.g. StringEquals or IpAddress.",
+									},
+									"variable": schema.StringAttribute{
+										Required:    true,
+										Description: "Condition key the values are compared to, e.g. aws:SourceIp.",
+									},
+									"values": schema.ListAttribute{
+										Required:    true,
+										ElementType: types.StringType,
+										Description: "Values the condition key is compared to. Any of them satisfies the condition",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *policyDocumentDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data policyDocumentModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	document := pkg.PolicyDocument{
+		Version: pkg.PolicyVersion,
+		Id:      data.PolicyId.ValueString(),
+	}
+	if !data.Version.IsNull() {
+		document.Version = data.Version.ValueString()
+	}
+
+	for i, source := range data.SourcePolicyDocuments {
+		sourceDocument, err := decodePolicyDocument(source)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("source_policy_documents").AtListIndex(i), "Invalid source policy document", err.Error())
+			continue
+		}
+		for _, statement := range sourceDocument.Statements {
+			if statement.Sid != "" && indexOfSid(document.Statements, statement.Sid) >= 0 {
+				resp.Diagnostics.AddAttributeError(path.Root("source_policy_documents").AtListIndex(i), "Duplicate statement sid",
+					fmt.Sprintf("The sid %q is used by more than one statement of the source policy documents.", statement.Sid))
+				continue
+			}
+			document.Statements = append(document.Statements, statement)
+		}
+	}
+
+	sids := map[string]bool{}
+	for i, statementModel := range data.Statements {
+		statement, diags := policyStatement(path.Root("statement").AtListIndex(i), statementModel)
+		resp.Diagnostics.Append(diags...)
+
+		if statement.Sid != "" {
+			if sids[statement.Sid] {
+				resp.Diagnostics.AddAttributeError(path.Root("statement").AtListIndex(i).AtName("sid"), "Duplicate statement sid",
+					fmt.Sprintf("The sid %q is used by more than one statement.", statement.Sid))
+				continue
+			}
+			sids[statement.Sid] = true
+		}
+		document.Statements = mergePolicyStatement(document.Statements, statement)
+	}
+
+	for i, override := range data.OverridePolicyDocuments {
+		overrideDocument, err := decodePolicyDocument(override)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("override_policy_documents").AtListIndex(i), "Invalid override policy document", err.Error())
+			continue
+		}
+		for _, statement := range overrideDocument.Statements {
+			document.Statements = mergePolicyStatement(document.Statements, statement)
+		}
+	}
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if document.Statements == nil {
+		document.Statements = []pkg.PolicyStatement{}
+	}
+
+	policy, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		resp.Diagnostics.AddError("Error encoding policy document", err.Error())
+		return
+	}
+
+	data.Json = types.StringValue(string(policy))
+	data.Id = types.StringValue(strconv.Itoa(int(crc32.ChecksumIEEE(policy))))
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// decodePolicyDocument decodes a source or override policy document.
+func decodePolicyDocument(policy string) (*pkg.PolicyDocument, error) {
+	var document pkg.PolicyDocument
+	if err := json.Unmarshal([]byte(policy), &document); err != nil {
+		return nil, fmt.Errorf("decoding policy document: %w", err)
+	}
+	return &document, nil
+}
+
+// mergePolicyStatement replaces the statement of statements with the sid of
+// statement, or appends statement when it has no sid or a new one.
+func mergePolicyStatement(statements []pkg.PolicyStatement, statement pkg.PolicyStatement) []pkg.PolicyStatement {
+	if i := indexOfSid(statements, statement.Sid); statement.Sid != "" && i >= 0 {
+		statements[i] = statement
+		return statements
+	}
+	return append(statements, statement)
+}
+
+func indexOfSid(statements []pkg.PolicyStatement, sid string) int {
+	for i, statement := range statements {
+		if statement.Sid == sid {
+			return i
+		}
+	}
+	return -1
+}
+
+// policyStatement converts a statement block into a policy statement. Sets
+// are sorted and single values are not wrapped in a list, so equal
+// configurations always produce the same document.
+func policyStatement(p path.Path, model policyStatementModel) (pkg.PolicyStatement, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	statement := pkg.PolicyStatement{
+		Sid:         model.Sid.ValueString(),
+		Effect:      policyEffectAllow,
+		Action:      policyValues(model.Actions, true),
+		NotAction:   policyValues(model.NotActions, true),
+		Resource:    policyValues(model.Resources, true),
+		NotResource: policyValues(model.NotResources, true),
+	}
+	if !model.Effect.IsNull() {
+		statement.Effect = model.Effect.ValueString()
+	}
+
+	if len(model.Principals) > 0 && len(model.NotPrincipals) > 0 {
+		diags.AddAttributeError(p.AtName("principals"), "Invalid policy statement", "principals conflicts with not_principals.")
+	}
+
+	var err error
+	if statement.Principal, err = policyPrincipal(model.Principals); err != nil {
+		diags.AddAttributeError(p.AtName("principals"), "Invalid policy principals", err.Error())
+	}
+	if statement.NotPrincipal, err = policyPrincipal(model.NotPrincipals); err != nil {
+		diags.AddAttributeError(p.AtName("not_principals"), "Invalid policy principals", err.Error())
+	}
+
+	for _, condition := range model.Conditions {
+		if statement.Condition == nil {
+			statement.Condition = map[string]map[string]interface{}{}
+		}
+		if statement.Condition[condition.Test] == nil {
+			statement.Condition[condition.Test] = map[string]interface{}{}
+		}
+		if _, ok := statement.Condition[condition.Test][condition.Variable]; ok {
+			diags.AddAttributeError(p.AtName("condition"), "Duplicate policy condition",
+				fmt.Sprintf("The variable %s is tested with %s more than once.", condition.Variable, condition.Test))
+			continue
+		}
+		statement.Condition[condition.Test][condition.Variable] = policyValues(condition.Values, false)
+	}
+
+	return statement, diags
+}
+
+// policyPrincipal returns the Principal of the principals blocks: * for
+// everyone, otherwise the identifiers by type.
+func policyPrincipal(principals []policyPrincipalModel) (interface{}, error) {
+	if len(principals) == 0 {
+		return nil, nil
+	}
+
+	identifiers := map[string][]string{}
+	for _, principal := range principals {
+		if principal.Type == policyPrincipalAll {
+			if len(principals) > 1 || len(principal.Identifiers) != 1 || principal.Identifiers[0] != policyPrincipalAll {
+				return nil, fmt.Errorf(`the * type must be alone with the identifiers ["*"]`)
+			}
+			return policyPrincipalAll, nil
+		}
+		identifiers[principal.Type] = append(identifiers[principal.Type], principal.Identifiers...)
+	}
+
+	principal := map[string]interface{}{}
+	for principalType, ids := range identifiers {
+		principal[principalType] = policyValues(ids, true)
+	}
+	return principal, nil
+}
+
+// policyValues returns nothing for no values, the value itself for a single
+// one and the list of values otherwise, sorted for sets.
+func policyValues(values []string, set bool) interface{} {
+	switch len(values) {
+	case 0:
+		return nil
+	case 1:
+		return values[0]
+	}
+
+	if set {
+		values = append([]string(nil), values...)
+		sort.Strings(values)
+	}
+	return values
+}
+
+// policyActionValidator rejects actions matching none of the S3 actions the
+// Object Storage supports in bucket policies.
+type policyActionValidator struct{}
+
+var _ validator.String = policyActionValidator{}
+
+func (v policyActionValidator) Description(_ context.Context) string {
+	return "value must be an S3 action supported by the Object Storage, e.g. s3:GetObject"
+}
+
+func (v policyActionValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v policyActionValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	if action := req.ConfigValue.ValueString(); !pkg.IsPolicyAction(action) {
+		resp.Diagnostics.AddAttributeError(req.Path, "Unsupported policy action",
+			fmt.Sprintf("Attribute %s %s, got: %s", req.Path, v.Description(ctx), action))
+	}
+}
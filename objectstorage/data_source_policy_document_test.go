@@ -0,0 +1,91 @@
+package objectstorage
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccPolicyDocumentDataSource_basic(t *testing.T) {
+	server := testAccServer(t)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testCheckBucketsDestroyed(server),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderConfig(server) + `
+resource "vcd-object-storage-ext_bucket" "test" {
+  name = "acc-policy-document"
+}
+
+data "vcd-object-storage-ext_policy_document" "base" {
+  statement {
+    sid       = "Read"
+    actions   = ["s3:GetObject"]
+    resources = ["arn:aws:s3:::acc-policy-document/*"]
+
+    principals {
+      type        = "*"
+      identifiers = ["*"]
+    }
+  }
+}
+
+data "vcd-object-storage-ext_policy_document" "test" {
+  source_policy_documents = [data.vcd-object-storage-ext_policy_document.base.json]
+
+  statement {
+    sid       = "Read"
+    actions   = ["s3:GetObject", "s3:ListBucket"]
+    resources = ["arn:aws:s3:::acc-policy-document", "arn:aws:s3:::acc-policy-document/*"]
+
+    principals {
+      type        = "*"
+      identifiers = ["*"]
+    }
+
+    condition {
+      test     = "IpAddress"
+      variable = "aws:SourceIp"
+      values   = ["10.0.0.0/8"]
+    }
+  }
+}
+
+resource "vcd-object-storage-ext_bucket_policy" "test" {
+  bucket = vcd-object-storage-ext_bucket.test.name
+  policy = data.vcd-object-storage-ext_policy_document.test.json
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestMatchResourceAttr("data.vcd-object-storage-ext_policy_document.test", "json", regexp.MustCompile(`"aws:SourceIp": "10.0.0.0/8"`)),
+					resource.TestMatchResourceAttr("data.vcd-object-storage-ext_policy_document.test", "json", regexp.MustCompile(`"s3:ListBucket"`)),
+					resource.TestCheckResourceAttrPair("vcd-object-storage-ext_bucket_policy.test", "policy", "data.vcd-object-storage-ext_policy_document.test", "json"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccPolicyDocumentDataSource_unsupportedAction(t *testing.T) {
+	server := testAccServer(t)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderConfig(server) + `
+data "vcd-object-storage-ext_policy_document" "test" {
+  statement {
+    actions   = ["s3:GetObjectRetention"]
+    resources = ["*"]
+  }
+}
+`,
+				ExpectError: regexp.MustCompile(`Unsupported policy action`),
+			},
+		},
+	})
+}
@@ -0,0 +1,35 @@
+package objectstorage
+
+import (
+	"fmt"
+
+	fwdiag "github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/josajunior81/terraform-provider-vcd-object-storage-ext/pkg"
+)
+
+// errorDiagnostic builds an error diagnostic for err, refining the summary
+// when err is an Object Storage API error.
+func errorDiagnostic(summary string, err error) diag.Diagnostic {
+	switch {
+	case pkg.IsAccessDenied(err):
+		summary += ": access denied"
+	case pkg.IsNotFound(err):
+		summary += ": not found"
+	case pkg.IsConflict(err):
+		summary += ": already exists or conflicts with the current state"
+	}
+
+	return diag.Diagnostic{
+		Severity: diag.Error,
+		Summary:  summary,
+		Detail:   fmt.Sprintf("%s: %v", summary, err),
+	}
+}
+
+// addErrorDiagnostic adds the errorDiagnostic of err to the diagnostics of a
+// terraform-plugin-framework response.
+func addErrorDiagnostic(diags *fwdiag.Diagnostics, summary string, err error) {
+	d := errorDiagnostic(summary, err)
+	diags.AddError(d.Summary, d.Detail)
+}
@@ -0,0 +1,174 @@
+package objectstorage
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/josajunior81/terraform-provider-vcd-object-storage-ext/pkg"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const bucketResourceType = "vcd-object-storage-ext_bucket"
+
+// RunGenerate implements the generate subcommand of the provider binary. It
+// configures the provider from the same environment variables Terraform
+// would use, e.g. S3_URL, API_TOKEN, ORG and VCD_URL, and writes the HCL of
+// every bucket to -out, or to stdout.
+func RunGenerate(args []string) int {
+	flags := flag.NewFlagSet("generate", flag.ContinueOnError)
+	out := flags.String("out", "", "File the configuration is written to. Default stdout")
+	prefix := flags.String("prefix", "", "Only generate buckets whose name starts with prefix")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: terraform-provider-vcd-object-storage-ext generate [-out file] [-prefix prefix]")
+		fmt.Fprintln(flags.Output(), "\nWrites a resource and an import block for every bucket visible to the configured credentials.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	ctx := context.Background()
+
+	p := Provider()
+	if diags := p.Configure(ctx, terraform.NewResourceConfigRaw(map[string]interface{}{})); diags.HasError() {
+		for _, d := range diags {
+			fmt.Fprintf(os.Stderr, "Error: %s: %s\n", d.Summary, d.Detail)
+		}
+		return 1
+	}
+
+	var file *os.File
+	w := io.Writer(os.Stdout)
+	if *out != "" {
+		var err error
+		file, err = os.Create(*out)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			return 1
+		}
+		w = file
+	}
+
+	err := GenerateBuckets(ctx, p.Meta().(pkg.ObjectStorage), w, *prefix)
+	// The configuration is only complete once the file is closed.
+	if file != nil {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+// GenerateBuckets writes a vcd-object-storage-ext_bucket resource and the
+// matching import block for every bucket whose name starts with prefix. The
+// attributes are read exactly as the bucket resource reads them, so the first
+// plan after applying the import is clean.
+func GenerateBuckets(ctx context.Context, storage pkg.ObjectStorage, w io.Writer, prefix string) error {
+	names, err := storage.ListBuckets(ctx)
+	if err != nil {
+		return fmt.Errorf("listing buckets: %w", err)
+	}
+
+	r := &bucketResource{storage: storage}
+	file := hclwrite.NewEmptyFile()
+	body := file.Body()
+	labels := map[string]bool{}
+
+	for _, name := range names {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		bucket := bucketModel{Id: types.StringValue(name)}
+		err := r.read(ctx, &bucket)
+		if pkg.IsNotFound(err) {
+			log.Printf("[WARN] Bucket %s disappeared while generating, skipping", name)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("reading bucket %s: %w", name, err)
+		}
+
+		label := resourceLabel(name, labels)
+
+		importBlock := body.AppendNewBlock("import", nil).Body()
+		importBlock.SetAttributeTraversal("to", hcl.Traversal{
+			hcl.TraverseRoot{Name: bucketResourceType},
+			hcl.TraverseAttr{Name: label},
+		})
+		importBlock.SetAttributeValue("id", cty.StringVal(name))
+		body.AppendNewline()
+
+		resource := body.AppendNewBlock("resource", []string{bucketResourceType, label}).Body()
+		resource.SetAttributeValue("name", cty.StringVal(name))
+		for _, tag := range bucket.Tags {
+			block := resource.AppendNewBlock("tag", nil).Body()
+			block.SetAttributeValue("name", cty.StringVal(tag.Name.ValueString()))
+			block.SetAttributeValue("value", cty.StringVal(tag.Value.ValueString()))
+		}
+		for _, acl := range bucket.Acls {
+			block := resource.AppendNewBlock("acl", nil).Body()
+			block.SetAttributeValue("user", cty.StringVal(acl.User.ValueString()))
+			block.SetAttributeValue("permission", cty.StringVal(acl.Permission.ValueString()))
+		}
+		for _, cors := range bucket.Cors {
+			block := resource.AppendNewBlock("cors", nil).Body()
+			setStringList(block, "allowed_headers", cors.AllowedHeaders)
+			setStringList(block, "allowed_methods", cors.AllowedMethods)
+			setStringList(block, "allowed_origins", cors.AllowedOrigins)
+			setStringList(block, "expose_headers", cors.ExposeHeaders)
+			block.SetAttributeValue("max_age_seconds", cty.NumberIntVal(cors.MaxAgeSeconds.ValueInt64()))
+		}
+		body.AppendNewline()
+	}
+
+	_, err = w.Write(file.Bytes())
+	return err
+}
+
+// setStringList writes a list of strings attribute. Empty lists are left
+// out.
+func setStringList(body *hclwrite.Body, name string, list types.List) {
+	if len(list.Elements()) == 0 {
+		return
+	}
+
+	values := make([]cty.Value, 0, len(list.Elements()))
+	for _, element := range list.Elements() {
+		values = append(values, cty.StringVal(element.(types.String).ValueString()))
+	}
+	body.SetAttributeValue(name, cty.ListVal(values))
+}
+
+var invalidLabelChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// resourceLabel turns a bucket name into a unique resource name.
+func resourceLabel(name string, used map[string]bool) string {
+	label := invalidLabelChars.ReplaceAllString(name, "_")
+	if label == "" || (label[0] >= '0' && label[0] <= '9') || label[0] == '-' {
+		label = "bucket_" + label
+	}
+
+	unique := label
+	for i := 2; used[unique]; i++ {
+		unique = fmt.Sprintf("%s_%d", label, i)
+	}
+	used[unique] = true
+
+	return unique
+}
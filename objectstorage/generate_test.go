@@ -0,0 +1,106 @@
+package objectstorage
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/josajunior81/terraform-provider-vcd-object-storage-ext/pkg"
+)
+
+var update = flag.Bool("update", false, "update the golden files of testdata")
+
+func TestGenerateBuckets(t *testing.T) {
+	ctx := context.Background()
+	server := testAccServer(t)
+	client := pkg.NewS3ClientWithKeys(server.URL, "us-east-1", "osetest", "osetest", "")
+
+	for _, name := range []string{"app-assets", "app.logs", "2024-backups", "other"} {
+		if err := client.CreateBucket(ctx, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := client.BucketTags(ctx, "app-assets", []pkg.Tag{
+		{Key: "env", Value: "prod"},
+		{Key: "team", Value: "web"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.BucketAcls(ctx, "app-assets", "", []pkg.BucketAcl{
+		{User: "PUBLIC", Permission: "READ"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.BucketCors(ctx, "app-assets", []pkg.CORSRule{{
+		AllowedHeaders: []string{"*"},
+		AllowedMethods: []string{"GET", "HEAD"},
+		AllowedOrigins: []string{"https://example.com"},
+		MaxAgeSeconds:  600,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got bytes.Buffer
+	if err := GenerateBuckets(ctx, client, &got, "app"); err != nil {
+		t.Fatal(err)
+	}
+	if err := GenerateBuckets(ctx, client, &got, "2024"); err != nil {
+		t.Fatal(err)
+	}
+
+	compareGolden(t, "generate.golden", got.Bytes())
+}
+
+func TestRunGenerate(t *testing.T) {
+	server := testAccServer(t)
+	if err := pkg.NewS3ClientWithKeys(server.URL, "us-east-1", "osetest", "osetest", "").CreateBucket(context.Background(), "app-assets"); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("S3_URL", server.URL)
+	t.Setenv("S3_ACCESS_KEY", "osetest")
+	t.Setenv("S3_SECRET_KEY", "osetest")
+
+	out := filepath.Join(t.TempDir(), "buckets.tf")
+	if code := RunGenerate([]string{"-out", out}); code != 0 {
+		t.Fatalf("RunGenerate = %d", code)
+	}
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(got, []byte(`resource "vcd-object-storage-ext_bucket" "app-assets"`)) {
+		t.Errorf("RunGenerate wrote:\n%s", got)
+	}
+
+	if code := RunGenerate([]string{"-out", filepath.Join(t.TempDir(), "missing", "buckets.tf")}); code != 1 {
+		t.Errorf("RunGenerate to a missing directory = %d, want 1", code)
+	}
+}
+
+// compareGolden compares got with testdata/name, which is rewritten instead
+// when the tests run with -update.
+func compareGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs, run go test -update to accept the new output.\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
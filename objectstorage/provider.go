@@ -6,29 +6,51 @@ import (
 	//
 
 	"context"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/josajunior81/terraform-provider-vcd-object-storage-ext/pkg"
 )
 
+// globalResourceMap holds the resources still served by SDKv2. The ones
+// migrated to terraform-plugin-framework are listed in frameworkProvider.
 var globalResourceMap = map[string]*schema.Resource{
-	"vcd-object-storage-ext_bucket": resourceBucket(),
 	"vcd-object-storage-ext_object": resourceObject(),
 }
 
-var globalDataSourceMap = map[string]*schema.Resource{
-	"vcd-object-storage-ext_bucket": dataSourceBucket(),
-}
+// globalDataSourceMap holds the data sources still served by SDKv2. The ones
+// migrated to terraform-plugin-framework are listed in frameworkProvider.
+var globalDataSourceMap = map[string]*schema.Resource{}
 
 func Provider() *schema.Provider {
 	return &schema.Provider{
 		Schema: map[string]*schema.Schema{
 			"s3_url": {
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc("S3_URL", nil),
-				Description: "The S3 url for Object Storage",
+				Description: "The S3 url for Object Storage. https is used unless the url starts with http://. Required",
+			},
+
+			"protocol": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				Default:          string(pkg.ProtocolOSE),
+				ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice([]string{string(pkg.ProtocolOSE), string(pkg.ProtocolS3)}, false)),
+				Description:      "API spoken with s3_url: ose for the Object Storage Extension JSON API under api/v1/s3, s3 for the standard S3 REST/XML API. Default ose",
+			},
+
+			"addressing": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				Default:          string(pkg.AddressingPath),
+				ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice([]string{string(pkg.AddressingPath), string(pkg.AddressingVirtualHosted)}, false)),
+				Description:      "Bucket addressing of the s3 protocol: path (host/bucket/key) or virtual-hosted (bucket.host/key). Default path",
 			},
 
 			"region": {
@@ -40,30 +62,150 @@ func Provider() *schema.Provider {
 
 			"org": {
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc("ORG", nil),
-				Description: "The org (tenat) Object Storage",
+				Description: "The org (tenat) Object Storage. Required with api_token",
 			},
 
 			"api_token": {
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc("API_TOKEN", nil),
-				Description: "The Api Token to access VCD",
+				Description: "The Api Token to access VCD. Conflicts with access_key",
 			},
 
 			"vcd_url": {
 				Type:        schema.TypeString,
-				Required:    true,
-				DefaultFunc: schema.EnvDefaultFunc("API_TOKEN", nil),
-				Description: "The VCD url",
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("VCD_URL", nil),
+				Description: "The VCD url. Required with api_token",
+			},
+
+			"access_key": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("S3_ACCESS_KEY", nil),
+				Description: "Access key of an Object Storage S3 user. Requests are signed with AWS Signature Version 4 and VCD is not contacted. Conflicts with api_token",
+			},
+
+			"secret_key": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				DefaultFunc: schema.EnvDefaultFunc("S3_SECRET_KEY", nil),
+				Description: "Secret key of an Object Storage S3 user. Required with access_key",
+			},
+
+			"session_token": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				DefaultFunc: schema.EnvDefaultFunc("S3_SESSION_TOKEN", nil),
+				Description: "Optional session token sent with access_key and secret_key",
 			},
 
 			"insecure": {
 				Type:        schema.TypeBool,
 				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc("INSECURE", false),
-				Description: "If set, VCDClient will permit unverifiable SSL certificates.",
+				Description: "If set, the VCD and S3 clients will permit unverifiable SSL certificates.",
+			},
+
+			"ca_file": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				DefaultFunc:   schema.EnvDefaultFunc("CA_FILE", nil),
+				ConflictsWith: []string{"ca_pem"},
+				Description:   "Path to a PEM encoded CA bundle trusted in addition to the system roots",
+			},
+
+			"ca_pem": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				DefaultFunc:   schema.EnvDefaultFunc("CA_PEM", nil),
+				ConflictsWith: []string{"ca_file"},
+				Description:   "PEM encoded CA certificates trusted in addition to the system roots",
+			},
+
+			"min_tls_version": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				Default:          "1.2",
+				ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice(pkg.TLSVersions(), false)),
+				Description:      "Minimum TLS version accepted from VCD and the S3 endpoint. Valid Values: 1.0 | 1.1 | 1.2 | 1.3. Default 1.2",
+			},
+
+			"retry_max_attempts": {
+				Type:             schema.TypeInt,
+				Optional:         true,
+				Default:          5,
+				ValidateDiagFunc: validation.ToDiagFunc(validation.IntAtLeast(1)),
+				Description:      "Maximum number of attempts for a request to Object Storage, including the first one. 1 disables retries. Default 5",
+			},
+
+			"retry_base_delay": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				Default:          "1s",
+				ValidateDiagFunc: validateDuration,
+				Description:      "Delay before the first retry, doubled on every attempt. Default 1s",
+			},
+
+			"retry_max_delay": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				Default:          "30s",
+				ValidateDiagFunc: validateDuration,
+				Description:      "Maximum delay between retries, also caps the Retry-After sent by the server. Default 30s",
+			},
+
+			"retry_jitter": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "If set, randomizes the delay between retries. Default true",
+			},
+
+			"retry_status_codes": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "HTTP status codes that are retried. Default 429, 502, 503 and 504",
+				Elem: &schema.Schema{
+					Type: schema.TypeInt,
+				},
+			},
+
+			"retry_methods": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "Idempotent HTTP methods that are retried. Default GET, HEAD, PUT, DELETE and OPTIONS",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+
+			"multipart_threshold": {
+				Type:             schema.TypeInt,
+				Optional:         true,
+				Default:          100,
+				ValidateDiagFunc: validation.ToDiagFunc(validation.IntAtLeast(0)),
+				Description:      "Object size, in MiB, from which objects are uploaded in parts. 0 disables multipart upload. Default 100",
+			},
+
+			"multipart_part_size": {
+				Type:             schema.TypeInt,
+				Optional:         true,
+				Default:          16,
+				ValidateDiagFunc: validation.ToDiagFunc(validation.IntAtLeast(5)),
+				Description:      "Size, in MiB, of each part of a multipart upload. Minimum 5. Default 16",
+			},
+
+			"multipart_concurrency": {
+				Type:             schema.TypeInt,
+				Optional:         true,
+				Default:          4,
+				ValidateDiagFunc: validation.ToDiagFunc(validation.IntAtLeast(1)),
+				Description:      "Number of parts uploaded in parallel. Default 4",
 			},
 		},
 		ResourcesMap:         globalResourceMap,
@@ -72,10 +214,98 @@ func Provider() *schema.Provider {
 	}
 }
 
+// ProviderWithStorage returns the provider wired to the given storage backend
+// instead of connecting to VCD, e.g. a pkg.MemoryStorage in unit tests.
+func ProviderWithStorage(storage pkg.ObjectStorage) *schema.Provider {
+	p := Provider()
+	p.ConfigureContextFunc = func(context.Context, *schema.ResourceData) (interface{}, diag.Diagnostics) {
+		return storage, nil
+	}
+	return p
+}
+
 func providerConfigure(_ context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
-	var providerDiag = diag.Diagnostics{}
+	return providerConfig(d).Client()
+}
 
-	s3client := pkg.NewS3Client(d.Get("s3_url").(string), d.Get("region").(string), d.Get("api_token").(string), d.Get("org").(string), d.Get("vcd_url").(string))
+// providerWithClients returns the provider taking its client from clients,
+// shared with the framework provider it is muxed with.
+func providerWithClients(clients *clientCache) *schema.Provider {
+	p := Provider()
+	p.ConfigureContextFunc = func(_ context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
+		return clients.client(providerConfig(d))
+	}
+	return p
+}
+
+func providerConfig(d *schema.ResourceData) Config {
+	return Config{
+		Url:           d.Get("s3_url").(string),
+		Region:        d.Get("region").(string),
+		Org:           d.Get("org").(string),
+		ApiToken:      d.Get("api_token").(string),
+		VcdUrl:        d.Get("vcd_url").(string),
+		AccessKey:     d.Get("access_key").(string),
+		SecretKey:     d.Get("secret_key").(string),
+		SessionToken:  d.Get("session_token").(string),
+		Insecure:      d.Get("insecure").(bool),
+		CaFile:        d.Get("ca_file").(string),
+		CaPem:         d.Get("ca_pem").(string),
+		MinTlsVersion: d.Get("min_tls_version").(string),
+		Protocol:      d.Get("protocol").(string),
+		Addressing:    d.Get("addressing").(string),
+		RetryPolicy:   retryPolicy(d),
+		Multipart:     multipartConfig(d),
+	}
+}
+
+func retryPolicy(d *schema.ResourceData) pkg.RetryPolicy {
+	policy := pkg.DefaultRetryPolicy()
+
+	policy.MaxAttempts = d.Get("retry_max_attempts").(int)
+	policy.BaseDelay, _ = time.ParseDuration(d.Get("retry_base_delay").(string))
+	policy.MaxDelay, _ = time.ParseDuration(d.Get("retry_max_delay").(string))
+	policy.Jitter = d.Get("retry_jitter").(bool)
+
+	if codes := d.Get("retry_status_codes").([]interface{}); len(codes) > 0 {
+		policy.RetryableStatusCodes = nil
+		for _, c := range codes {
+			policy.RetryableStatusCodes = append(policy.RetryableStatusCodes, c.(int))
+		}
+	}
+
+	if methods := d.Get("retry_methods").([]interface{}); len(methods) > 0 {
+		policy.RetryableMethods = nil
+		for _, m := range methods {
+			policy.RetryableMethods = append(policy.RetryableMethods, strings.ToUpper(m.(string)))
+		}
+	}
+
+	return policy
+}
+
+func multipartConfig(d *schema.ResourceData) pkg.MultipartConfig {
+	return pkg.MultipartConfig{
+		Threshold:   int64(d.Get("multipart_threshold").(int)) << 20,
+		PartSize:    int64(d.Get("multipart_part_size").(int)) << 20,
+		Concurrency: d.Get("multipart_concurrency").(int),
+	}
+}
+
+func validateDuration(v interface{}, p cty.Path) diag.Diagnostics {
+	value := v.(string)
+	var diags diag.Diagnostics
+
+	if _, err := time.ParseDuration(value); err != nil {
+		diag := diag.Diagnostic{
+			Severity:      diag.Error,
+			Summary:       "Wrong value. Must be a duration such as 500ms, 1s or 2m",
+			Detail:        fmt.Sprintf("%q is not a valid duration: %v", value, err),
+			AttributePath: p,
+		}
+
+		diags = append(diags, diag)
+	}
 
-	return s3client, providerDiag
+	return diags
 }
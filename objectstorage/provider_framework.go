@@ -0,0 +1,273 @@
+package objectstorage
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-mux/tf5muxserver"
+	sdkdiag "github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/josajunior81/terraform-provider-vcd-object-storage-ext/pkg"
+)
+
+// frameworkProvider serves the resources and data sources migrated to
+// terraform-plugin-framework. It is muxed with the SDKv2 Provider, so its
+// schema must stay identical to the SDKv2 one: every attribute is optional
+// and Configure applies the defaults of the SDKv2 schema.
+type frameworkProvider struct {
+	storage pkg.ObjectStorage
+	clients *clientCache
+}
+
+var _ provider.Provider = (*frameworkProvider)(nil)
+
+// ProviderServerFactory returns the protocol 5 server of the provider, the
+// SDKv2 Provider and the framework provider muxed together while resources
+// are migrated one at a time. Both share the client, so the VCD token is
+// exchanged once.
+func ProviderServerFactory(ctx context.Context) (func() tfprotov5.ProviderServer, error) {
+	clients := newClientCache()
+
+	return muxProviders(ctx, &frameworkProvider{clients: clients}, providerWithClients(clients).GRPCProvider)
+}
+
+// ProviderServerFactoryWithStorage returns the protocol 5 server of the
+// provider with both halves wired to the given storage backend, e.g. a
+// pkg.MemoryStorage in unit tests.
+func ProviderServerFactoryWithStorage(ctx context.Context, storage pkg.ObjectStorage) (func() tfprotov5.ProviderServer, error) {
+	return muxProviders(ctx, NewFrameworkProviderWithStorage(storage), ProviderWithStorage(storage).GRPCProvider)
+}
+
+func muxProviders(ctx context.Context, frameworkProvider provider.Provider, sdkProvider func() tfprotov5.ProviderServer) (func() tfprotov5.ProviderServer, error) {
+	providers := []func() tfprotov5.ProviderServer{
+		providerserver.NewProtocol5(frameworkProvider),
+		sdkProvider,
+	}
+
+	muxServer, err := tf5muxserver.NewMuxServer(ctx, providers...)
+	if err != nil {
+		return nil, err
+	}
+
+	return muxServer.ProviderServer, nil
+}
+
+func NewFrameworkProvider() provider.Provider {
+	return &frameworkProvider{}
+}
+
+// NewFrameworkProviderWithStorage returns the framework provider wired to the
+// given storage backend instead of connecting to VCD, like ProviderWithStorage.
+func NewFrameworkProviderWithStorage(storage pkg.ObjectStorage) provider.Provider {
+	return &frameworkProvider{storage: storage}
+}
+
+func (p *frameworkProvider) Metadata(_ context.Context, _ provider.MetadataRequest, resp *provider.MetadataResponse) {
+	resp.TypeName = "vcd-object-storage-ext"
+}
+
+// Schema mirrors the schema of Provider. Descriptions and sensitivity must
+// match exactly, the mux server rejects differing provider schemas. The
+// validators are those of Provider; defaults are applied in Configure.
+func (p *frameworkProvider) Schema(_ context.Context, _ provider.SchemaRequest, resp *provider.SchemaResponse) {
+	sdkSchema := Provider().Schema
+
+	stringValidators := map[string][]validator.String{
+		"protocol":         {stringvalidator.OneOf(string(pkg.ProtocolOSE), string(pkg.ProtocolS3))},
+		"addressing":       {stringvalidator.OneOf(string(pkg.AddressingPath), string(pkg.AddressingVirtualHosted))},
+		"ca_file":          {stringvalidator.ConflictsWith(path.MatchRoot("ca_pem"))},
+		"ca_pem":           {stringvalidator.ConflictsWith(path.MatchRoot("ca_file"))},
+		"min_tls_version":  {stringvalidator.OneOf(pkg.TLSVersions()...)},
+		"retry_base_delay": {durationValidator{}},
+		"retry_max_delay":  {durationValidator{}},
+	}
+	int64Validators := map[string][]validator.Int64{
+		"retry_max_attempts":    {int64validator.AtLeast(1)},
+		"multipart_threshold":   {int64validator.AtLeast(0)},
+		"multipart_part_size":   {int64validator.AtLeast(5)},
+		"multipart_concurrency": {int64validator.AtLeast(1)},
+	}
+
+	attributes := map[string]schema.Attribute{}
+	for name, s := range sdkSchema {
+		switch name {
+		case "insecure", "retry_jitter":
+			attributes[name] = schema.BoolAttribute{Optional: true, Description: s.Description, Sensitive: s.Sensitive}
+		case "retry_max_attempts", "multipart_threshold", "multipart_part_size", "multipart_concurrency":
+			attributes[name] = schema.Int64Attribute{Optional: true, Description: s.Description, Sensitive: s.Sensitive, Validators: int64Validators[name]}
+		case "retry_status_codes":
+			attributes[name] = schema.ListAttribute{Optional: true, Description: s.Description, ElementType: types.Int64Type}
+		case "retry_methods":
+			attributes[name] = schema.ListAttribute{Optional: true, Description: s.Description, ElementType: types.StringType}
+		default:
+			attributes[name] = schema.StringAttribute{Optional: true, Description: s.Description, Sensitive: s.Sensitive, Validators: stringValidators[name]}
+		}
+	}
+
+	resp.Schema = schema.Schema{Attributes: attributes}
+}
+
+// Configure builds the client through the SDKv2 provider, which owns the
+// defaults and the environment variables of the settings, so both halves of
+// the muxed provider build the same client.
+func (p *frameworkProvider) Configure(ctx context.Context, req provider.ConfigureRequest, resp *provider.ConfigureResponse) {
+	raw, err := sdkConfig(req.Config.Raw)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid provider configuration", err.Error())
+		return
+	}
+
+	sdkProvider := providerWithClients(p.clients)
+	if p.storage != nil {
+		sdkProvider = ProviderWithStorage(p.storage)
+	}
+
+	for _, d := range sdkProvider.Configure(ctx, terraform.NewResourceConfigRaw(raw)) {
+		if d.Severity == sdkdiag.Error {
+			resp.Diagnostics.AddError(d.Summary, d.Detail)
+		} else {
+			resp.Diagnostics.AddWarning(d.Summary, d.Detail)
+		}
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	client := sdkProvider.Meta().(pkg.ObjectStorage)
+	resp.DataSourceData = client
+	resp.ResourceData = client
+}
+
+func (p *frameworkProvider) Resources(_ context.Context) []func() resource.Resource {
+	return []func() resource.Resource{
+		newBucketResource,
+		newBucketLifecycleConfigurationResource,
+		newBucketPolicyResource,
+		newBucketServerSideEncryptionResource,
+		newBucketLoggingResource,
+	}
+}
+
+func (p *frameworkProvider) DataSources(_ context.Context) []func() datasource.DataSource {
+	return []func() datasource.DataSource{
+		newBucketDataSource,
+		newPolicyDocumentDataSource,
+	}
+}
+
+// storageFromProviderData returns the client set by Configure, or nil while
+// the provider is not configured yet, e.g. during validation.
+func storageFromProviderData(data any, diags *diag.Diagnostics) pkg.ObjectStorage {
+	if data == nil {
+		return nil
+	}
+
+	storage, ok := data.(pkg.ObjectStorage)
+	if !ok {
+		diags.AddError("Unexpected provider data", fmt.Sprintf("Expected pkg.ObjectStorage, got %T", data))
+		return nil
+	}
+
+	return storage
+}
+
+// sdkConfig converts the provider configuration into the raw configuration
+// of the SDKv2 provider. Null and unknown settings are left out and take
+// their default.
+func sdkConfig(config tftypes.Value) (map[string]interface{}, error) {
+	var attributes map[string]tftypes.Value
+	if err := config.As(&attributes); err != nil {
+		return nil, err
+	}
+
+	raw := map[string]interface{}{}
+	for name, value := range attributes {
+		if value.IsNull() || !value.IsFullyKnown() {
+			continue
+		}
+
+		v, err := sdkConfigValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		raw[name] = v
+	}
+
+	return raw, nil
+}
+
+func sdkConfigValue(value tftypes.Value) (interface{}, error) {
+	switch {
+	case value.Type().Is(tftypes.String):
+		var s string
+		err := value.As(&s)
+		return s, err
+	case value.Type().Is(tftypes.Bool):
+		var b bool
+		err := value.As(&b)
+		return b, err
+	case value.Type().Is(tftypes.Number):
+		var n big.Float
+		if err := value.As(&n); err != nil {
+			return nil, err
+		}
+		i, _ := n.Int64()
+		return int(i), nil
+	case value.Type().Is(tftypes.List{}):
+		var elements []tftypes.Value
+		if err := value.As(&elements); err != nil {
+			return nil, err
+		}
+		list := make([]interface{}, 0, len(elements))
+		for _, element := range elements {
+			v, err := sdkConfigValue(element)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	}
+
+	return nil, fmt.Errorf("unsupported type %s", value.Type())
+}
+
+// durationValidator rejects values time.ParseDuration does not accept, like
+// validateDuration.
+type durationValidator struct{}
+
+var _ validator.String = durationValidator{}
+
+func (v durationValidator) Description(_ context.Context) string {
+	return "value must be a duration such as 500ms, 1s or 2m"
+}
+
+func (v durationValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v durationValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	if _, err := time.ParseDuration(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid duration",
+			fmt.Sprintf("Attribute %s %s, got: %s", req.Path, v.Description(ctx), req.ConfigValue.ValueString()))
+	}
+}
@@ -0,0 +1,270 @@
+package objectstorage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/josajunior81/terraform-provider-vcd-object-storage-ext/pkg"
+	"github.com/josajunior81/terraform-provider-vcd-object-storage-ext/pkg/osetest"
+)
+
+// testAccProtoV5ProviderFactories serves the muxed provider, as main does.
+var testAccProtoV5ProviderFactories = map[string]func() (tfprotov5.ProviderServer, error){
+	"vcd-object-storage-ext": func() (tfprotov5.ProviderServer, error) {
+		factory, err := ProviderServerFactory(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		return factory(), nil
+	},
+}
+
+// protoV5ProviderFactories serves the muxed provider with both halves wired
+// to storage.
+func protoV5ProviderFactories(storage pkg.ObjectStorage) map[string]func() (tfprotov5.ProviderServer, error) {
+	return map[string]func() (tfprotov5.ProviderServer, error){
+		"vcd-object-storage-ext": func() (tfprotov5.ProviderServer, error) {
+			factory, err := ProviderServerFactoryWithStorage(context.Background(), storage)
+			if err != nil {
+				return nil, err
+			}
+			return factory(), nil
+		},
+	}
+}
+
+// testUnitPreCheck skips unit tests when no Terraform CLI is available, which
+// resource.UnitTest would otherwise download.
+func testUnitPreCheck(t *testing.T) {
+	t.Helper()
+
+	if os.Getenv("TF_ACC_TERRAFORM_PATH") != "" || os.Getenv("TF_ACC_TERRAFORM_VERSION") != "" {
+		return
+	}
+	if _, err := exec.LookPath("terraform"); err != nil {
+		t.Skip("terraform CLI not found on the PATH, set TF_ACC_TERRAFORM_PATH to run unit tests")
+	}
+}
+
+// testBackend is a storage the resource tests run against: the OSE emulator,
+// reached through the S3 client once TF_ACC is set, or pkg.MemoryStorage
+// wired to the provider.
+type testBackend struct {
+	providerConfig string
+	factories      map[string]func() (tfprotov5.ProviderServer, error)
+	storage        bucketLister
+	object         func(bucket, key string) ([]byte, bool)
+	unit           bool
+}
+
+// test runs c against the backend and checks that every bucket is destroyed
+// at the end.
+func (b testBackend) test(t *testing.T, c resource.TestCase) {
+	c.ProtoV5ProviderFactories = b.factories
+	c.CheckDestroy = testCheckBucketsDestroyed(b.storage)
+
+	if b.unit {
+		c.PreCheck = func() { testUnitPreCheck(t) }
+		resource.UnitTest(t, c)
+		return
+	}
+	resource.Test(t, c)
+}
+
+// testBackends runs fn against a new emulator and a new MemoryStorage.
+func testBackends(t *testing.T, fn func(t *testing.T, backend testBackend)) {
+	t.Run("osetest", func(t *testing.T) {
+		server := testAccServer(t)
+		fn(t, testBackend{
+			providerConfig: testAccProviderConfig(server),
+			factories:      testAccProtoV5ProviderFactories,
+			storage:        server,
+			object:         server.Object,
+		})
+	})
+
+	t.Run("memory", func(t *testing.T) {
+		storage := pkg.NewMemoryStorage("")
+		fn(t, testBackend{
+			factories: protoV5ProviderFactories(storage),
+			storage:   storage,
+			object: func(bucket, key string) ([]byte, bool) {
+				object, ok := storage.Object(bucket, key)
+				return object.Data, ok
+			},
+			unit: true,
+		})
+	})
+}
+
+// testAccServer starts an OSE emulator for the duration of the test.
+func testAccServer(t *testing.T) *osetest.Server {
+	t.Helper()
+
+	server := osetest.NewServer()
+	t.Cleanup(server.Close)
+	return server
+}
+
+// testAccProviderConfig configures the provider against the emulator with S3
+// user keys, so no VCD is needed.
+func testAccProviderConfig(server *osetest.Server) string {
+	return fmt.Sprintf(`
+provider "vcd-object-storage-ext" {
+  s3_url           = %q
+  access_key       = "osetest"
+  secret_key       = "osetest"
+  retry_base_delay = "10ms"
+  retry_max_delay  = "100ms"
+}
+`, server.URL)
+}
+
+func TestProvider(t *testing.T) {
+	if err := Provider().InternalValidate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProviderValidate(t *testing.T) {
+	tests := []struct {
+		config  map[string]interface{}
+		wantErr bool
+	}{
+		{map[string]interface{}{"retry_max_attempts": 1}, false},
+		{map[string]interface{}{"retry_max_attempts": 0}, true},
+		{map[string]interface{}{"retry_max_attempts": -3}, true},
+		{map[string]interface{}{"retry_base_delay": "soon"}, true},
+		{map[string]interface{}{"retry_max_delay": "250ms"}, false},
+		{map[string]interface{}{"multipart_part_size": 4}, true},
+		{map[string]interface{}{"protocol": "s3", "addressing": "virtual-hosted"}, false},
+		{map[string]interface{}{"protocol": "swift"}, true},
+		{map[string]interface{}{"min_tls_version": "1.4"}, true},
+		{map[string]interface{}{"ca_file": "ca.pem", "ca_pem": "pem"}, true},
+	}
+
+	ctx := context.Background()
+	frameworkServer := providerserver.NewProtocol5(NewFrameworkProvider())()
+
+	for _, test := range tests {
+		diags := Provider().Validate(terraform.NewResourceConfigRaw(test.config))
+		if diags.HasError() != test.wantErr {
+			t.Errorf("Validate(%v) = %v, want error %v", test.config, diags, test.wantErr)
+		}
+
+		// The framework provider validates the same settings.
+		values := map[string]tftypes.Value{}
+		for name, value := range test.config {
+			switch value := value.(type) {
+			case int:
+				values[name] = tftypes.NewValue(tftypes.Number, value)
+			default:
+				values[name] = tftypes.NewValue(tftypes.String, value)
+			}
+		}
+		resp, err := frameworkServer.PrepareProviderConfig(ctx, &tfprotov5.PrepareProviderConfigRequest{
+			Config: providerConfigValue(t, frameworkServer, values),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hasError(resp.Diagnostics) != test.wantErr {
+			t.Errorf("framework PrepareProviderConfig(%v) = %v, want error %v", test.config, resp.Diagnostics, test.wantErr)
+		}
+	}
+}
+
+// providerConfigValue returns the provider configuration of server with the
+// given values and every other setting null.
+func providerConfigValue(t *testing.T, server tfprotov5.ProviderServer, values map[string]tftypes.Value) *tfprotov5.DynamicValue {
+	t.Helper()
+
+	schemaResp, err := server.GetProviderSchema(context.Background(), &tfprotov5.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	configType := schemaResp.Provider.Block.ValueType().(tftypes.Object)
+
+	attributes := map[string]tftypes.Value{}
+	for name, typ := range configType.AttributeTypes {
+		attributes[name] = tftypes.NewValue(typ, nil)
+	}
+	for name, value := range values {
+		attributes[name] = value
+	}
+
+	config, err := tfprotov5.NewDynamicValue(configType, tftypes.NewValue(configType, attributes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &config
+}
+
+func hasError(diags []*tfprotov5.Diagnostic) bool {
+	for _, d := range diags {
+		if d.Severity == tfprotov5.DiagnosticSeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+func TestProviderSchemasMatch(t *testing.T) {
+	// The mux server refuses to start when the SDKv2 and the framework
+	// provider schemas differ.
+	factory, err := ProviderServerFactory(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := factory().GetProviderSchema(context.Background(), &tfprotov5.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range resp.Diagnostics {
+		t.Errorf("%s: %s", d.Summary, d.Detail)
+	}
+}
+
+func TestProviderConfigureExchangesTokenOnce(t *testing.T) {
+	ctx := context.Background()
+	server := testAccServer(t)
+
+	factory, err := ProviderServerFactory(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	providerServer := factory()
+
+	values := map[string]tftypes.Value{}
+	for name, value := range map[string]string{
+		"s3_url":           server.URL,
+		"api_token":        osetest.APIToken,
+		"org":              "osetest",
+		"vcd_url":          server.URL,
+		"retry_base_delay": "10ms",
+	} {
+		values[name] = tftypes.NewValue(tftypes.String, value)
+	}
+	config := providerConfigValue(t, providerServer, values)
+
+	resp, err := providerServer.ConfigureProvider(ctx, &tfprotov5.ConfigureProviderRequest{Config: config})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range resp.Diagnostics {
+		t.Errorf("%s: %s", d.Summary, d.Detail)
+	}
+
+	if exchanges := server.TokenExchanges(); exchanges != 1 {
+		t.Errorf("configuring the muxed provider exchanged %d tokens, want 1", exchanges)
+	}
+}
@@ -2,306 +2,639 @@ package objectstorage
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
-
-	"github.com/google/uuid"
-	"github.com/hashicorp/go-cty/cty"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"reflect"
+	"regexp"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/josajunior81/terraform-provider-vcd-object-storage-ext/pkg"
 )
 
-func resourceBucket() *schema.Resource {
-	return &schema.Resource{
-		CreateContext: resourceBucketCreate,
-		ReadContext:   resourceBucketRead,
-		UpdateContext: resourceBucketUpdate,
-		DeleteContext: resourceBucketDelete,
-		Description:   "A bucket is a container for storing objects in a compartment within an Object Storage namespace.",
-
-		Schema: map[string]*schema.Schema{
-			"last_updated": {
-				Type:     schema.TypeString,
-				Computed: true,
+type bucketResource struct {
+	storage pkg.ObjectStorage
+}
+
+var (
+	_ resource.ResourceWithConfigure      = (*bucketResource)(nil)
+	_ resource.ResourceWithImportState    = (*bucketResource)(nil)
+	_ resource.ResourceWithValidateConfig = (*bucketResource)(nil)
+	_ resource.ResourceWithUpgradeState   = (*bucketResource)(nil)
+)
+
+type bucketModel struct {
+	Id          types.String      `tfsdk:"id"`
+	LastUpdated types.String      `tfsdk:"last_updated"`
+	Name        types.String      `tfsdk:"name"`
+	CannedAcl   types.String      `tfsdk:"canned_acl"`
+	Tags        []bucketTagModel  `tfsdk:"tag"`
+	Acls        []bucketAclModel  `tfsdk:"acl"`
+	Cors        []bucketCorsModel `tfsdk:"cors"`
+	Timeouts    timeouts.Value    `tfsdk:"timeouts"`
+}
+
+type bucketTagModel struct {
+	Name  types.String `tfsdk:"name"`
+	Value types.String `tfsdk:"value"`
+}
+
+type bucketAclModel struct {
+	User       types.String `tfsdk:"user"`
+	Permission types.String `tfsdk:"permission"`
+}
+
+type bucketCorsModel struct {
+	AllowedHeaders types.List  `tfsdk:"allowed_headers"`
+	ExposeHeaders  types.List  `tfsdk:"expose_headers"`
+	AllowedMethods types.List  `tfsdk:"allowed_methods"`
+	AllowedOrigins types.List  `tfsdk:"allowed_origins"`
+	MaxAgeSeconds  types.Int64 `tfsdk:"max_age_seconds"`
+}
+
+// bucketModelV0 is the model of version 0, whose id is a random UUID.
+type bucketModelV0 struct {
+	Id          types.String      `tfsdk:"id"`
+	LastUpdated types.String      `tfsdk:"last_updated"`
+	Name        types.String      `tfsdk:"name"`
+	CannedAcl   types.String      `tfsdk:"canned_acl"`
+	Tags        []bucketTagModel  `tfsdk:"tag"`
+	Acls        []bucketAclModel  `tfsdk:"acl"`
+	Cors        []bucketCorsModel `tfsdk:"cors"`
+}
+
+var (
+	bucketCannedAcls = []string{"private", "public-read", "public-read-write", "authenticated-read", "group-read-write", "group-read", "log-delivery-write"}
+	bucketAclUsers   = []string{"TENANT", "AUTHENTICATED", "PUBLIC", "SYSTEM-LOGGER"}
+	bucketAclGrants  = []string{"FULL_CONTROL", "READ", "WRITE", "READ_ACP", "WRITE_ACP"}
+	corsMethods      = []string{"GET", "PUT", "POST", "DELETE", "HEAD"}
+
+	// corsOrigin accepts an origin such as https://www.example.com, * or
+	// http://*.example.com. S3 allows at most one * wildcard per origin.
+	corsOrigin = regexp.MustCompile(`^[^*]*\*?[^*]*$`)
+)
+
+func newBucketResource() resource.Resource {
+	return &bucketResource{}
+}
+
+func (r *bucketResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_bucket"
+}
+
+func (r *bucketResource) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "A bucket is a container for storing objects in a compartment within an Object Storage namespace.",
+		Version:     1,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:    true,
+				Description: "The bucket name.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"last_updated": schema.StringAttribute{
+				Computed:           true,
+				DeprecationMessage: "last_updated is never set and will be removed in the next major version.",
+				Description:        "Never set.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
 			},
-			"name": {
-				Type:        schema.TypeString,
+			"name": schema.StringAttribute{
 				Required:    true,
-				ForceNew:    true,
 				Description: "The bucket name. It must be URL encoded.",
-			},
-
-			"canned_acl": {
-				Type:     schema.TypeString,
-				Optional: true,
-				ForceNew: false,
-				ValidateDiagFunc: func(v interface{}, p cty.Path) diag.Diagnostics {
-					value := v.(string)
-					var diags diag.Diagnostics
-
-					switch value {
-					case "private", "public-read", "public-read-write", "authenticated-read", "group-read-write", "group-read", "log-delivery-write":
-						return diags
-					default:
-						diag := diag.Diagnostic{
-							Severity:      diag.Error,
-							Summary:       "Wrong value. Valid Values: private | public-read | public-read-write | authenticated-read | group-read-write | group-read | log-delivery-write",
-							Detail:        fmt.Sprintf("%q is not x-amz-acl valid value", value),
-							AttributePath: p,
-						}
-
-						return append(diags, diag)
-					}
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
 				},
-				Description: "The ACL of the bucket using the specified canned ACL. Valid Values: private | public-read | public-read-write | authenticated-read.",
 			},
-
-			"tag": {
-				Type:        schema.TypeList,
+			"canned_acl": schema.StringAttribute{
 				Optional:    true,
-				ForceNew:    false,
+				Description: "The ACL of the bucket using the specified canned ACL. Valid Values: private | public-read | public-read-write | authenticated-read.",
+				Validators: []validator.String{
+					stringvalidator.OneOf(bucketCannedAcls...),
+				},
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"tag": schema.ListNestedBlock{
 				Description: "The bucket tags.",
-				Elem: &schema.Resource{
-					Schema: map[string]*schema.Schema{
-						"name": {
-							Type:     schema.TypeString,
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"name": schema.StringAttribute{
 							Required: true,
 						},
-						"value": {
-							Type:     schema.TypeString,
+						"value": schema.StringAttribute{
 							Required: true,
 						},
 					},
 				},
 			},
-
-			"acl": {
-				Type:        schema.TypeList,
-				Optional:    true,
-				ForceNew:    false,
-				Description: "Access control lists (ACLs) enable you to manage access to buckets and objects.",
-				Elem: &schema.Resource{
-					Schema: map[string]*schema.Schema{
-						"user": {
-							Type:     schema.TypeString,
-							Required: true,
-							ValidateDiagFunc: func(v interface{}, p cty.Path) diag.Diagnostics {
-								value := v.(string)
-								var diags diag.Diagnostics
-
-								if value != "TENANT" && value != "AUTHENTICATED" && value != "PUBLIC" && value != "SYSTEM-LOGGER" {
-									diag := diag.Diagnostic{
-										Severity:      diag.Error,
-										Summary:       "Wrong value. Valid Values: TENANT | AUTHENTICATED | PUBLIC | SYSTEM-LOGGER",
-										Detail:        fmt.Sprintf("%q is not a valid ACL User", value),
-										AttributePath: p,
-									}
-
-									diags = append(diags, diag)
-								}
-
-								return diags
-							},
+			"acl": schema.ListNestedBlock{
+				Description: "Access control lists (ACLs) enable you to manage access to buckets and objects. Conflicts with canned_acl",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"user": schema.StringAttribute{
+							Required:    true,
 							Description: "ACL users. Valid Values: TENANT | AUTHENTICATED | PUBLIC | SYSTEM-LOGGER",
-						},
-						"permission": {
-							Type:     schema.TypeString,
-							Required: true,
-							ValidateDiagFunc: func(i interface{}, p cty.Path) diag.Diagnostics {
-								value := i.(string)
-								var diags diag.Diagnostics
-
-								if value != "FULL_CONTROL" && value != "READ" && value != "WRITE" && value != "READ_ACP" && value != "WRITE_ACP" {
-									diag := diag.Diagnostic{
-										Severity:      diag.Error,
-										Summary:       "Wrong value. Valid Values: FULL_CONTROL | READ | WRITE | READ_ACP | WRITE_ACP",
-										Detail:        fmt.Sprintf("%q is not a valid ACL Permission", value),
-										AttributePath: p,
-									}
-
-									diags = append(diags, diag)
-								}
-
-								return diags
+							Validators: []validator.String{
+								stringvalidator.OneOf(bucketAclUsers...),
 							},
+						},
+						"permission": schema.StringAttribute{
+							Required:    true,
 							Description: "ACL permission. Valid Values: FULL_CONTROL | READ | WRITE | READ_ACP | WRITE_ACP",
+							Validators: []validator.String{
+								stringvalidator.OneOf(bucketAclGrants...),
+							},
 						},
 					},
 				},
 			},
-
-			"cors": {
-				Type:        schema.TypeList,
-				Optional:    true,
-				ForceNew:    false,
+			"cors": schema.ListNestedBlock{
 				Description: "Cross-origin resource sharing (CORS) defines a way for client web applications that are loaded in one domain to interact with resources in a different domain.",
-				Elem: &schema.Resource{
-					Schema: map[string]*schema.Schema{
-						"allowed_headers": {
-							Type:        schema.TypeList,
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"allowed_headers": schema.ListAttribute{
 							Optional:    true,
+							ElementType: types.StringType,
 							Description: "The allowed_headers element specifies which headers are allowed in a preflight request through the Access-Control-Request-Headers header. Must be a comma separated string",
-							Elem: &schema.Schema{
-								Type: schema.TypeString,
-							},
 						},
-						"expose_headers": {
-							Type:        schema.TypeList,
+						"expose_headers": schema.ListAttribute{
 							Optional:    true,
+							ElementType: types.StringType,
 							Description: "Each expose_headers element identifies a header in the response that you want customers to be able to access from their applications (for example, from a JavaScript XMLHttpRequest object). Must be a comma separated string",
-							Elem: &schema.Schema{
-								Type: schema.TypeString,
-							},
 						},
-						"allowed_methods": {
-							Type:        schema.TypeList,
+						"allowed_methods": schema.ListAttribute{
 							Required:    true,
+							ElementType: types.StringType,
 							Description: "In the CORS configuration, you can specify the following values for the allowed_methods element GET | PUT | POST | DELETE | HEAD. Must be a comma separated string",
-							Elem: &schema.Schema{
-								Type: schema.TypeString,
+							Validators: []validator.List{
+								listvalidator.ValueStringsAre(stringvalidator.OneOf(corsMethods...)),
 							},
 						},
-						"allowed_origins": {
-							Type:        schema.TypeList,
+						"allowed_origins": schema.ListAttribute{
 							Required:    true,
-							Description: "In the allowed_origins element, you specify the origins that you want to allow cross-domain requests from. Must be a comma separated string",
-							Elem: &schema.Schema{
-								Type: schema.TypeString,
+							ElementType: types.StringType,
+							Description: "In the allowed_origins element, you specify the origins that you want to allow cross-domain requests from. Each origin can contain at most one * wildcard. Must be a comma separated string",
+							Validators: []validator.List{
+								listvalidator.ValueStringsAre(
+									stringvalidator.LengthAtLeast(1),
+									stringvalidator.RegexMatches(corsOrigin, "must contain at most one * wildcard"),
+								),
 							},
 						},
-						"max_age_seconds": {
-							Type:        schema.TypeInt,
+						"max_age_seconds": schema.Int64Attribute{
 							Optional:    true,
-							Default:     3600,
+							Computed:    true,
+							Default:     int64default.StaticInt64(3600),
 							Description: "Max age in secods. Default 3600",
 						},
 					},
 				},
 			},
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Update: true,
+				Delete: true,
+			}),
 		},
 	}
 }
 
-// Creates Bucket on the Object Storage
-func resourceBucketCreate(c context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	s3client := meta.(pkg.S3Client)
+func (r *bucketResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.storage = storageFromProviderData(req.ProviderData, &resp.Diagnostics)
+}
 
-	var diags diag.Diagnostics
+// ValidateConfig rejects acl blocks combined with a canned ACL, which
+// replaces their grants, and acl blocks that grant the same permission to
+// the same user twice. Such grants are merged by the Object Storage and
+// would show as a change on every plan.
+func (r *bucketResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var cannedAcl types.String
+	var acls types.List
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("canned_acl"), &cannedAcl)...)
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("acl"), &acls)...)
+	if resp.Diagnostics.HasError() || acls.IsNull() || acls.IsUnknown() {
+		return
+	}
 
-	d.SetId(uuid.NewString())
-	bucketName := d.Get("name").(string)
+	if !cannedAcl.IsNull() && len(acls.Elements()) > 0 {
+		resp.Diagnostics.AddAttributeError(path.Root("canned_acl"), "Invalid Attribute Combination",
+			"canned_acl conflicts with acl, set either a canned ACL or acl blocks.")
+	}
 
-	err := s3client.CreateBucket(bucketName)
-	if err != nil {
-		diag := diag.Diagnostic{
-			Severity: diag.Error,
-			Summary:  "Error creating bucket",
-			Detail:   fmt.Sprintf("Error creating bucket: %v", err),
+	var models []bucketAclModel
+	resp.Diagnostics.Append(acls.ElementsAs(ctx, &models, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	seen := map[string]bool{}
+	for i, acl := range models {
+		if acl.User.IsNull() || acl.User.IsUnknown() || acl.Permission.IsNull() || acl.Permission.IsUnknown() {
+			// Unknown until apply.
+			continue
+		}
+
+		user, permission := acl.User.ValueString(), acl.Permission.ValueString()
+		grant := user + "/" + permission
+		if seen[grant] {
+			resp.Diagnostics.AddAttributeError(path.Root("acl").AtListIndex(i), "Duplicate ACL grant",
+				fmt.Sprintf("acl.%d: user %s is granted %s more than once.", i, user, permission))
 		}
-		return append(diags, diag)
+		seen[grant] = true
 	}
+}
 
-	diags = resourceBucketUpdate(c, d, meta)
+// Creates Bucket on the Object Storage
+func (r *bucketResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan bucketModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	timeout, diags := plan.Timeouts.Create(ctx, 10*time.Minute)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	name := plan.Name.ValueString()
+	if err := r.storage.CreateBucket(ctx, name); err != nil {
+		addErrorDiagnostic(&resp.Diagnostics, "Error creating bucket", err)
+		return
+	}
 
-	resourceBucketRead(c, d, meta)
-	return diags
+	// The bucket is kept in state, tainted, when configuring it fails.
+	plan.Id = types.StringValue(name)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), name)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("name"), name)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r.apply(ctx, plan, nil, &resp.State, &resp.Diagnostics)
 }
 
 // Reads Bucket from Object Storage
-func resourceBucketRead(c context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	resourceID := d.Id()
-	log.Println(">>> resourceID:", resourceID)
-	return nil
+func (r *bucketResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state bucketModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	err := r.read(ctx, &state)
+	if pkg.IsNotFound(err) {
+		log.Printf("[WARN] Bucket %s not found, removing from state", state.Id.ValueString())
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		addErrorDiagnostic(&resp.Diagnostics, "Error reading bucket", err)
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
 }
 
-func resourceBucketUpdate(c context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	// resourceID := d.Id()
-	s3client := meta.(pkg.S3Client)
+func (r *bucketResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state bucketModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-	var diags diag.Diagnostics
+	timeout, diags := plan.Timeouts.Update(ctx, 10*time.Minute)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	r.apply(ctx, plan, &state, &resp.State, &resp.Diagnostics)
+}
+
+// Deletes Bucket at the Object Storage
+func (r *bucketResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state bucketModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	timeout, diags := state.Timeouts.Delete(ctx, 30*time.Minute)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if err := r.storage.DeleteBucket(ctx, state.Name.ValueString()); err != nil && !pkg.IsNotFound(err) {
+		addErrorDiagnostic(&resp.Diagnostics, "Error deleting bucket", err)
+	}
+}
+
+// Imports a Bucket by name, which is also its ID
+func (r *bucketResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
+
+// UpgradeState replaces the random UUID ID of version 0 with the bucket name.
+func (r *bucketResource) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	priorSchema := bucketSchemaV0()
+
+	return map[int64]resource.StateUpgrader{
+		0: {
+			PriorSchema: &priorSchema,
+			StateUpgrader: func(ctx context.Context, req resource.UpgradeStateRequest, resp *resource.UpgradeStateResponse) {
+				var prior bucketModelV0
+				resp.Diagnostics.Append(req.State.Get(ctx, &prior)...)
+				if resp.Diagnostics.HasError() {
+					return
+				}
+
+				state := bucketModel{
+					Id:          prior.Name,
+					LastUpdated: prior.LastUpdated,
+					Name:        prior.Name,
+					CannedAcl:   prior.CannedAcl,
+					Tags:        prior.Tags,
+					Acls:        prior.Acls,
+					Cors:        prior.Cors,
+					Timeouts: timeouts.Value{Object: types.ObjectNull(map[string]attr.Type{
+						"create": types.StringType,
+						"update": types.StringType,
+						"delete": types.StringType,
+					})},
+				}
+				resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+			},
+		},
+	}
+}
+
+// bucketSchemaV0 is the schema of version 0, frozen so the state upgrade
+// keeps decoding old states when the current schema changes.
+func bucketSchemaV0() schema.Schema {
+	stringList := schema.ListAttribute{
+		Optional:    true,
+		ElementType: types.StringType,
+	}
+
+	return schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed: true,
+			},
+			"last_updated": schema.StringAttribute{
+				Computed: true,
+			},
+			"name": schema.StringAttribute{
+				Required: true,
+			},
+			"canned_acl": schema.StringAttribute{
+				Optional: true,
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"tag": schema.ListNestedBlock{
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"name": schema.StringAttribute{
+							Required: true,
+						},
+						"value": schema.StringAttribute{
+							Required: true,
+						},
+					},
+				},
+			},
+			"acl": schema.ListNestedBlock{
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"user": schema.StringAttribute{
+							Required: true,
+						},
+						"permission": schema.StringAttribute{
+							Required: true,
+						},
+					},
+				},
+			},
+			"cors": schema.ListNestedBlock{
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"allowed_headers": stringList,
+						"expose_headers":  stringList,
+						"allowed_methods": stringList,
+						"allowed_origins": stringList,
+						"max_age_seconds": schema.Int64Attribute{
+							Optional: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
 
-	bucketName := d.Get("name").(string)
-	cannedAcl := d.Get("canned_acl").(string)
-	tags := d.Get("tag").([]any)
-	acls := d.Get("acl").([]interface{})
-	cors := d.Get("cors").([]interface{})
+// apply sets the planned ACL, tags and CORS rules of the bucket and stores
+// the bucket read back in state. The tags and CORS rules are only sent when
+// they differ from prior, the state before an update.
+func (r *bucketResource) apply(ctx context.Context, plan bucketModel, prior *bucketModel, state *tfsdk.State, diags *diag.Diagnostics) {
+	name := plan.Name.ValueString()
 
-	log.Printf("cors %v", cors)
+	// A canned ACL replaces the acl blocks, which conflict with it.
+	if err := r.storage.BucketAcls(ctx, name, plan.CannedAcl.ValueString(), bucketAcls(plan.Acls)); err != nil {
+		addErrorDiagnostic(diags, "Error editing bucket ACLs", err)
+		return
+	}
 
-	if cannedAcl != "" && len(acls) > 0 {
-		diag := diag.Diagnostic{
-			Severity: diag.Error,
-			Summary:  "You have to choose between Canned ACL or ACL rules",
-			Detail:   "Choose between Canned ACL or ACL rules",
+	if prior == nil || !reflect.DeepEqual(plan.Tags, prior.Tags) {
+		if err := r.storage.BucketTags(ctx, name, bucketTags(plan.Tags)); err != nil {
+			addErrorDiagnostic(diags, "Error editing bucket TAGs", err)
+			return
 		}
-		return append(diags, diag)
-	}
-
-	if len(acls) > 0 {
-		err := s3client.BucketAcls(bucketName, false, cannedAcl, acls)
-		if err != nil {
-			diag := diag.Diagnostic{
-				Severity: diag.Error,
-				Summary:  "Error editing bucket ACLs",
-				Detail:   fmt.Sprintf("Error editing bucket ACLs: %v", err),
-			}
-			return append(diags, diag)
+	}
+
+	if prior == nil || !reflect.DeepEqual(plan.Cors, prior.Cors) {
+		rules, d := bucketCorsRules(ctx, plan.Cors)
+		diags.Append(d...)
+		if diags.HasError() {
+			return
 		}
-	} else {
-		err := s3client.BucketAcls(bucketName, true, cannedAcl, nil)
-		if err != nil {
-			diag := diag.Diagnostic{
-				Severity: diag.Error,
-				Summary:  "Error editing bucket ACLs",
-				Detail:   fmt.Sprintf("Error editing bucket ACLs: %v", err),
-			}
-			return append(diags, diag)
+		if err := r.storage.BucketCors(ctx, name, rules); err != nil {
+			addErrorDiagnostic(diags, "Error editing bucket CORs", err)
+			return
 		}
 	}
 
-	if len(tags) > 0 {
-		err := s3client.BucketTags(bucketName, tags)
-		if err != nil {
-			diag := diag.Diagnostic{
-				Severity: diag.Error,
-				Summary:  "Error editing bucket TAGs",
-				Detail:   fmt.Sprintf("Error editing bucket TAGs: %v", err),
-			}
-			return append(diags, diag)
-		}
+	if err := r.read(ctx, &plan); err != nil {
+		addErrorDiagnostic(diags, "Error reading bucket", err)
+		return
 	}
 
-	if len(cors) > 0 {
-		err := s3client.BucketCors(bucketName, cors)
-		if err != nil {
-			diag := diag.Diagnostic{
-				Severity: diag.Error,
-				Summary:  "Error editing bucket CORs",
-				Detail:   fmt.Sprintf("Error editing bucket CORs: %v", err),
-			}
-			return append(diags, diag)
+	diags.Append(state.Set(ctx, &plan)...)
+}
+
+// read sets the live bucket whose name is the id of model on model. The
+// lists the bucket returns empty keep the null or empty value of model, so
+// that omitted and empty lists both plan cleanly.
+func (r *bucketResource) read(ctx context.Context, model *bucketModel) error {
+	name := model.Id.ValueString()
+
+	bucketStr, err := r.storage.GetBucket(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	var bucket pkg.Bucket
+	if err := json.Unmarshal([]byte(bucketStr), &bucket); err != nil {
+		return err
+	}
+
+	tags, err := r.storage.GetBucketTags(ctx, name)
+	if err != nil {
+		return fmt.Errorf("reading TAGs: %w", err)
+	}
+
+	acl, err := r.storage.GetBucketAcl(ctx, name)
+	if err != nil {
+		return fmt.Errorf("reading ACLs: %w", err)
+	}
+
+	cors, err := r.storage.GetBucketCors(ctx, name)
+	if err != nil {
+		return fmt.Errorf("reading CORs: %w", err)
+	}
+
+	model.Name = types.StringValue(bucket.Name)
+	model.LastUpdated = types.StringNull()
+	// Version 1 states written by SDKv2 store an unset canned ACL as "".
+	if model.CannedAcl.ValueString() == "" {
+		model.CannedAcl = types.StringNull()
+	}
+
+	model.Tags = make([]bucketTagModel, 0, len(tags))
+	for _, tag := range tags {
+		model.Tags = append(model.Tags, bucketTagModel{
+			Name:  types.StringValue(tag.Key),
+			Value: types.StringValue(tag.Value),
+		})
+	}
+
+	// A canned ACL expands into grants that have no acl block of their own,
+	// so the grants are only tracked when no canned ACL is configured.
+	if model.CannedAcl.IsNull() {
+		model.Acls = bucketAclModels(&bucket, acl)
+	} else if model.Acls == nil {
+		model.Acls = []bucketAclModel{}
+	}
+
+	corsModels := make([]bucketCorsModel, 0, len(cors))
+	for i, rule := range cors {
+		var prior bucketCorsModel
+		if i < len(model.Cors) {
+			prior = model.Cors[i]
 		}
+		corsModels = append(corsModels, bucketCorsModel{
+			AllowedHeaders: stringList(rule.AllowedHeaders, prior.AllowedHeaders),
+			ExposeHeaders:  stringList(rule.ExposeHeaders, prior.ExposeHeaders),
+			AllowedMethods: stringList(rule.AllowedMethods, prior.AllowedMethods),
+			AllowedOrigins: stringList(rule.AllowedOrigins, prior.AllowedOrigins),
+			MaxAgeSeconds:  types.Int64Value(int64(rule.MaxAgeSeconds)),
+		})
 	}
-	return diags
+	model.Cors = corsModels
+
+	return nil
 }
 
-// Deletes Bucket at the Object Storage
-func resourceBucketDelete(c context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	resourceID := d.Id()
-	log.Println(">>> resourceID:", resourceID)
+func bucketTags(models []bucketTagModel) []pkg.Tag {
+	var tags []pkg.Tag
+	for _, tag := range models {
+		tags = append(tags, pkg.Tag{Key: tag.Name.ValueString(), Value: tag.Value.ValueString()})
+	}
+	return tags
+}
 
-	var diags diag.Diagnostics
+func bucketAcls(models []bucketAclModel) []pkg.BucketAcl {
+	var acls []pkg.BucketAcl
+	for _, acl := range models {
+		acls = append(acls, pkg.BucketAcl{User: acl.User.ValueString(), Permission: acl.Permission.ValueString()})
+	}
+	return acls
+}
 
-	s3client := meta.(pkg.S3Client)
-	bucketName := d.Get("name").(string)
-	if err := s3client.DeleteBucket(bucketName); err != nil {
-		diag := diag.Diagnostic{
-			Severity: diag.Error,
-			Summary:  "Error editing bucket TAGs",
-			Detail:   fmt.Sprintf("Error editing bucket TAGs: %v", err),
+func bucketAclModels(bucket *pkg.Bucket, acl *pkg.AccessControlPolicy) []bucketAclModel {
+	models := []bucketAclModel{}
+	for _, grant := range acl.Grants {
+		user, ok := pkg.GrantUser(bucket, grant)
+		if !ok {
+			continue
 		}
-		return append(diags, diag)
+		models = append(models, bucketAclModel{
+			User:       types.StringValue(user),
+			Permission: types.StringValue(grant.Permission),
+		})
+	}
+	return models
+}
+
+// bucketCorsRules converts the cors blocks of a bucket into CORS rules.
+func bucketCorsRules(ctx context.Context, models []bucketCorsModel) ([]pkg.CORSRule, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	var rules []pkg.CORSRule
+	for _, cors := range models {
+		var rule pkg.CORSRule
+		diags.Append(cors.AllowedHeaders.ElementsAs(ctx, &rule.AllowedHeaders, false)...)
+		diags.Append(cors.ExposeHeaders.ElementsAs(ctx, &rule.ExposeHeaders, false)...)
+		diags.Append(cors.AllowedMethods.ElementsAs(ctx, &rule.AllowedMethods, false)...)
+		diags.Append(cors.AllowedOrigins.ElementsAs(ctx, &rule.AllowedOrigins, false)...)
+		rule.MaxAgeSeconds = int(cors.MaxAgeSeconds.ValueInt64())
+		rules = append(rules, rule)
+	}
+
+	return rules, diags
+}
+
+// stringList converts values into a list. No values keep the null or empty
+// value of prior.
+func stringList(values []string, prior types.List) types.List {
+	if len(values) == 0 && prior.IsNull() {
+		return types.ListNull(types.StringType)
+	}
+	if len(values) == 0 && !prior.IsUnknown() && len(prior.Elements()) == 0 {
+		return prior
+	}
+
+	elements := make([]attr.Value, 0, len(values))
+	for _, value := range values {
+		elements = append(elements, types.StringValue(value))
 	}
-	return diags
+	return types.ListValueMust(types.StringType, elements)
 }
@@ -0,0 +1,382 @@
+package objectstorage
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/mapvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/josajunior81/terraform-provider-vcd-object-storage-ext/pkg"
+)
+
+// lifecycleDateFormat is the format of expiration dates in the configuration,
+// the S3 API expects them at midnight UTC in ISO 8601 format.
+const lifecycleDateFormat = "2006-01-02"
+
+type bucketLifecycleConfigurationResource struct {
+	bucketSubresource[bucketLifecycleConfigurationModel]
+}
+
+var (
+	_ resource.ResourceWithConfigure      = (*bucketLifecycleConfigurationResource)(nil)
+	_ resource.ResourceWithImportState    = (*bucketLifecycleConfigurationResource)(nil)
+	_ resource.ResourceWithValidateConfig = (*bucketLifecycleConfigurationResource)(nil)
+)
+
+type bucketLifecycleConfigurationModel struct {
+	Id     types.String         `tfsdk:"id"`
+	Bucket types.String         `tfsdk:"bucket"`
+	Rules  []lifecycleRuleModel `tfsdk:"rule"`
+}
+
+type lifecycleRuleModel struct {
+	Id                             types.String                          `tfsdk:"id"`
+	Status                         types.String                          `tfsdk:"status"`
+	Filter                         []lifecycleFilterModel                `tfsdk:"filter"`
+	Expiration                     []lifecycleExpirationModel            `tfsdk:"expiration"`
+	NoncurrentVersionExpiration    []noncurrentVersionExpirationModel    `tfsdk:"noncurrent_version_expiration"`
+	AbortIncompleteMultipartUpload []abortIncompleteMultipartUploadModel `tfsdk:"abort_incomplete_multipart_upload"`
+}
+
+type lifecycleFilterModel struct {
+	Prefix types.String `tfsdk:"prefix"`
+	Tags   types.Map    `tfsdk:"tags"`
+}
+
+type lifecycleExpirationModel struct {
+	Days types.Int64  `tfsdk:"days"`
+	Date types.String `tfsdk:"date"`
+}
+
+type noncurrentVersionExpirationModel struct {
+	NoncurrentDays types.Int64 `tfsdk:"noncurrent_days"`
+}
+
+type abortIncompleteMultipartUploadModel struct {
+	DaysAfterInitiation types.Int64 `tfsdk:"days_after_initiation"`
+}
+
+func newBucketLifecycleConfigurationResource() resource.Resource {
+	r := &bucketLifecycleConfigurationResource{}
+	r.bucketSubresource = bucketSubresource[bucketLifecycleConfigurationModel]{name: "lifecycle configuration", ops: r}
+	return r
+}
+
+func (r *bucketLifecycleConfigurationResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_bucket_lifecycle_configuration"
+}
+
+func (r *bucketLifecycleConfigurationResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Manages the lifecycle rules of a bucket, which expire objects and abort incomplete multipart uploads. The rules of the bucket are replaced as a whole.",
+		Attributes:  bucketSubresourceAttributes(map[string]schema.Attribute{}),
+		Blocks: map[string]schema.Block{
+			"rule": schema.ListNestedBlock{
+				Description: "A lifecycle rule. At least one of expiration, noncurrent_version_expiration and abort_incomplete_multipart_upload is required.",
+				Validators: []validator.List{
+					listvalidator.IsRequired(),
+					listvalidator.SizeAtLeast(1),
+				},
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"id": schema.StringAttribute{
+							Required:    true,
+							Description: "Unique identifier of the rule.",
+							Validators: []validator.String{
+								stringvalidator.LengthBetween(1, 255),
+							},
+						},
+						"status": schema.StringAttribute{
+							Required:    true,
+							Description: "Whether the rule is applied. Valid Values: Enabled | Disabled",
+							Validators: []validator.String{
+								stringvalidator.OneOf(pkg.LifecycleEnabled, pkg.LifecycleDisabled),
+							},
+						},
+					},
+					Blocks: map[string]schema.Block{
+						"filter": schema.ListNestedBlock{
+							Description: "Objects the rule applies to. The rule applies to every object of the bucket without filter.",
+							Validators: []validator.List{
+								listvalidator.SizeAtMost(1),
+							},
+							NestedObject: schema.NestedBlockObject{
+								Attributes: map[string]schema.Attribute{
+									"prefix": schema.StringAttribute{
+										Optional:    true,
+										Description: "Key prefix of the objects.",
+										Validators: []validator.String{
+											stringvalidator.LengthAtLeast(1),
+											stringvalidator.AtLeastOneOf(path.MatchRelative().AtParent().AtName("tags")),
+										},
+									},
+									"tags": schema.MapAttribute{
+										Optional:    true,
+										ElementType: types.StringType,
+										Description: "Tags the objects must all carry.",
+										Validators: []validator.Map{
+											mapvalidator.SizeAtLeast(1),
+										},
+									},
+								},
+							},
+						},
+						"expiration": schema.ListNestedBlock{
+							Description: "Expiration of the current object versions. In versioned buckets a delete marker replaces them.",
+							Validators: []validator.List{
+								listvalidator.SizeAtMost(1),
+							},
+							NestedObject: schema.NestedBlockObject{
+								Attributes: map[string]schema.Attribute{
+									"days": schema.Int64Attribute{
+										Optional:    true,
+										Description: "Days after their creation the objects expire. Conflicts with date",
+										Validators: []validator.Int64{
+											int64validator.AtLeast(1),
+											int64validator.ExactlyOneOf(path.MatchRelative().AtParent().AtName("date")),
+										},
+									},
+									"date": schema.StringAttribute{
+										Optional:    true,
+										Description: "Date the objects expire, in YYYY-MM-DD format. Conflicts with days",
+										Validators: []validator.String{
+											stringvalidator.RegexMatches(regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`), "must be a date in YYYY-MM-DD format"),
+										},
+									},
+								},
+							},
+						},
+						"noncurrent_version_expiration": schema.ListNestedBlock{
+							Description: "Expiration of the noncurrent object versions of a versioned bucket.",
+							Validators: []validator.List{
+								listvalidator.SizeAtMost(1),
+							},
+							NestedObject: schema.NestedBlockObject{
+								Attributes: map[string]schema.Attribute{
+									"noncurrent_days": schema.Int64Attribute{
+										Required:    true,
+										Description: "Days after becoming noncurrent the object versions expire.",
+										Validators: []validator.Int64{
+											int64validator.AtLeast(1),
+										},
+									},
+								},
+							},
+						},
+						"abort_incomplete_multipart_upload": schema.ListNestedBlock{
+							Description: "Abort of the multipart uploads that were not completed.",
+							Validators: []validator.List{
+								listvalidator.SizeAtMost(1),
+							},
+							NestedObject: schema.NestedBlockObject{
+								Attributes: map[string]schema.Attribute{
+									"days_after_initiation": schema.Int64Attribute{
+										Required:    true,
+										Description: "Days after their initiation the uploads are aborted.",
+										Validators: []validator.Int64{
+											int64validator.AtLeast(1),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// ValidateConfig rejects rules without action and duplicate rule ids, which
+// the Object Storage would only refuse during apply.
+func (r *bucketLifecycleConfigurationResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var rules types.List
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("rule"), &rules)...)
+	if resp.Diagnostics.HasError() || rules.IsNull() || rules.IsUnknown() {
+		return
+	}
+
+	var models []lifecycleRuleModel
+	resp.Diagnostics.Append(rules.ElementsAs(ctx, &models, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ids := map[string]bool{}
+	for i, rule := range models {
+		if len(rule.Expiration) == 0 && len(rule.NoncurrentVersionExpiration) == 0 && len(rule.AbortIncompleteMultipartUpload) == 0 {
+			resp.Diagnostics.AddAttributeError(path.Root("rule").AtListIndex(i), "Invalid lifecycle rule",
+				"At least one of expiration, noncurrent_version_expiration and abort_incomplete_multipart_upload is required.")
+		}
+
+		if rule.Id.IsNull() || rule.Id.IsUnknown() {
+			continue
+		}
+		if ids[rule.Id.ValueString()] {
+			resp.Diagnostics.AddAttributeError(path.Root("rule").AtListIndex(i).AtName("id"), "Duplicate lifecycle rule",
+				fmt.Sprintf("The rule id %q is used more than once.", rule.Id.ValueString()))
+		}
+		ids[rule.Id.ValueString()] = true
+	}
+}
+
+// put replaces the lifecycle rules of the bucket with the planned ones.
+func (r *bucketLifecycleConfigurationResource) put(ctx context.Context, plan bucketLifecycleConfigurationModel, diags *diag.Diagnostics) error {
+	var lifecycle pkg.LifecycleConfiguration
+	for _, rule := range plan.Rules {
+		lifecycleRule, d := lifecycleRule(ctx, rule)
+		diags.Append(d...)
+		lifecycle.Rules = append(lifecycle.Rules, lifecycleRule)
+	}
+	if diags.HasError() {
+		return nil
+	}
+
+	return r.storage.BucketLifecycle(ctx, plan.Bucket.ValueString(), lifecycle)
+}
+
+// read sets the live lifecycle rules of the bucket on model.
+func (r *bucketLifecycleConfigurationResource) read(ctx context.Context, model *bucketLifecycleConfigurationModel) (bool, error) {
+	bucket := model.Bucket.ValueString()
+
+	rules, err := r.storage.GetBucketLifecycle(ctx, bucket)
+	if err != nil {
+		return false, err
+	}
+
+	model.Rules = make([]lifecycleRuleModel, 0, len(rules))
+	for _, rule := range rules {
+		ruleModel, err := lifecycleRuleModelOf(ctx, rule)
+		if err != nil {
+			return false, fmt.Errorf("reading lifecycle rule %s of bucket %s: %w", rule.Id, bucket, err)
+		}
+		model.Rules = append(model.Rules, ruleModel)
+	}
+
+	return len(rules) > 0, nil
+}
+
+func (r *bucketLifecycleConfigurationResource) remove(ctx context.Context, bucket string) error {
+	return r.storage.BucketLifecycle(ctx, bucket, pkg.LifecycleConfiguration{})
+}
+
+// lifecycleRule converts a rule block into a lifecycle rule payload.
+func lifecycleRule(ctx context.Context, rule lifecycleRuleModel) (pkg.LifecycleRule, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	lifecycleRule := pkg.LifecycleRule{
+		Id:     rule.Id.ValueString(),
+		Status: rule.Status.ValueString(),
+		Filter: pkg.NewLifecycleFilter("", nil),
+	}
+
+	for _, filter := range rule.Filter {
+		var tagMap map[string]string
+		diags.Append(filter.Tags.ElementsAs(ctx, &tagMap, false)...)
+
+		tags := make([]pkg.Tag, 0, len(tagMap))
+		for key, value := range tagMap {
+			tags = append(tags, pkg.Tag{Key: key, Value: value})
+		}
+		sort.Slice(tags, func(i, j int) bool { return tags[i].Key < tags[j].Key })
+
+		lifecycleRule.Filter = pkg.NewLifecycleFilter(filter.Prefix.ValueString(), tags)
+	}
+
+	for _, expiration := range rule.Expiration {
+		lifecycleRule.Expiration = &pkg.LifecycleExpiration{Days: int(expiration.Days.ValueInt64())}
+		if date := expiration.Date.ValueString(); date != "" {
+			t, err := time.Parse(lifecycleDateFormat, date)
+			if err != nil {
+				diags.AddError("Invalid expiration date", fmt.Sprintf("Expiration date %q of rule %s: %v", date, lifecycleRule.Id, err))
+				continue
+			}
+			lifecycleRule.Expiration.Date = t.Format(time.RFC3339)
+		}
+	}
+
+	for _, expiration := range rule.NoncurrentVersionExpiration {
+		lifecycleRule.NoncurrentVersionExpiration = &pkg.NoncurrentVersionExpiration{
+			NoncurrentDays: int(expiration.NoncurrentDays.ValueInt64()),
+		}
+	}
+
+	for _, abort := range rule.AbortIncompleteMultipartUpload {
+		lifecycleRule.AbortIncompleteMultipartUpload = &pkg.AbortIncompleteMultipartUpload{
+			DaysAfterInitiation: int(abort.DaysAfterInitiation.ValueInt64()),
+		}
+	}
+
+	return lifecycleRule, diags
+}
+
+// lifecycleRuleModelOf converts a lifecycle rule payload into a rule block.
+func lifecycleRuleModelOf(ctx context.Context, rule pkg.LifecycleRule) (lifecycleRuleModel, error) {
+	model := lifecycleRuleModel{
+		Id:                             types.StringValue(rule.Id),
+		Status:                         types.StringValue(rule.Status),
+		Filter:                         []lifecycleFilterModel{},
+		Expiration:                     []lifecycleExpirationModel{},
+		NoncurrentVersionExpiration:    []noncurrentVersionExpirationModel{},
+		AbortIncompleteMultipartUpload: []abortIncompleteMultipartUploadModel{},
+	}
+
+	if prefix, tags := rule.Filter.PrefixAndTags(); prefix != "" || len(tags) > 0 {
+		filter := lifecycleFilterModel{Prefix: types.StringNull(), Tags: types.MapNull(types.StringType)}
+		if prefix != "" {
+			filter.Prefix = types.StringValue(prefix)
+		}
+		if len(tags) > 0 {
+			tagMap := make(map[string]string, len(tags))
+			for _, tag := range tags {
+				tagMap[tag.Key] = tag.Value
+			}
+			var diags diag.Diagnostics
+			filter.Tags, diags = types.MapValueFrom(ctx, types.StringType, tagMap)
+			if diags.HasError() {
+				return model, fmt.Errorf("converting tags: %v", diags)
+			}
+		}
+		model.Filter = append(model.Filter, filter)
+	}
+
+	if expiration := rule.Expiration; expiration != nil {
+		expirationModel := lifecycleExpirationModel{Days: types.Int64Null(), Date: types.StringNull()}
+		if expiration.Days != 0 {
+			expirationModel.Days = types.Int64Value(int64(expiration.Days))
+		}
+		if expiration.Date != "" {
+			date, err := time.Parse(time.RFC3339, expiration.Date)
+			if err != nil {
+				return model, fmt.Errorf("parsing expiration date: %w", err)
+			}
+			expirationModel.Date = types.StringValue(date.UTC().Format(lifecycleDateFormat))
+		}
+		model.Expiration = append(model.Expiration, expirationModel)
+	}
+
+	if expiration := rule.NoncurrentVersionExpiration; expiration != nil {
+		model.NoncurrentVersionExpiration = append(model.NoncurrentVersionExpiration, noncurrentVersionExpirationModel{
+			NoncurrentDays: types.Int64Value(int64(expiration.NoncurrentDays)),
+		})
+	}
+
+	if abort := rule.AbortIncompleteMultipartUpload; abort != nil {
+		model.AbortIncompleteMultipartUpload = append(model.AbortIncompleteMultipartUpload, abortIncompleteMultipartUploadModel{
+			DaysAfterInitiation: types.Int64Value(int64(abort.DaysAfterInitiation)),
+		})
+	}
+
+	return model, nil
+}
@@ -0,0 +1,137 @@
+package objectstorage
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccBucketLifecycleConfiguration_basic(t *testing.T) {
+	server := testAccServer(t)
+
+	bucket := testAccProviderConfig(server) + `
+resource "vcd-object-storage-ext_bucket" "test" {
+  name = "acc-lifecycle"
+}
+`
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testCheckBucketsDestroyed(server),
+		Steps: []resource.TestStep{
+			{
+				Config: bucket + `
+resource "vcd-object-storage-ext_bucket_lifecycle_configuration" "test" {
+  bucket = vcd-object-storage-ext_bucket.test.name
+
+  rule {
+    id     = "backups"
+    status = "Enabled"
+
+    filter {
+      prefix = "backups/"
+      tags = {
+        kind = "database"
+      }
+    }
+
+    expiration {
+      days = 30
+    }
+  }
+
+  rule {
+    id     = "uploads"
+    status = "Enabled"
+
+    noncurrent_version_expiration {
+      noncurrent_days = 7
+    }
+
+    abort_incomplete_multipart_upload {
+      days_after_initiation = 1
+    }
+  }
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("vcd-object-storage-ext_bucket_lifecycle_configuration.test", "id", "acc-lifecycle"),
+					resource.TestCheckResourceAttr("vcd-object-storage-ext_bucket_lifecycle_configuration.test", "rule.#", "2"),
+					resource.TestCheckResourceAttr("vcd-object-storage-ext_bucket_lifecycle_configuration.test", "rule.0.filter.0.tags.kind", "database"),
+					resource.TestCheckResourceAttr("vcd-object-storage-ext_bucket_lifecycle_configuration.test", "rule.0.expiration.0.days", "30"),
+					resource.TestCheckResourceAttr("vcd-object-storage-ext_bucket_lifecycle_configuration.test", "rule.1.abort_incomplete_multipart_upload.0.days_after_initiation", "1"),
+				),
+			},
+			{
+				Config: bucket + `
+resource "vcd-object-storage-ext_bucket_lifecycle_configuration" "test" {
+  bucket = vcd-object-storage-ext_bucket.test.name
+
+  rule {
+    id     = "backups"
+    status = "Disabled"
+
+    expiration {
+      date = "2030-01-01"
+    }
+  }
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("vcd-object-storage-ext_bucket_lifecycle_configuration.test", "rule.#", "1"),
+					resource.TestCheckResourceAttr("vcd-object-storage-ext_bucket_lifecycle_configuration.test", "rule.0.filter.#", "0"),
+					resource.TestCheckResourceAttr("vcd-object-storage-ext_bucket_lifecycle_configuration.test", "rule.0.expiration.0.date", "2030-01-01"),
+				),
+			},
+			{
+				ResourceName:      "vcd-object-storage-ext_bucket_lifecycle_configuration.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestAccBucketLifecycleConfiguration_invalidConfiguration(t *testing.T) {
+	server := testAccServer(t)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderConfig(server) + `
+resource "vcd-object-storage-ext_bucket_lifecycle_configuration" "test" {
+  bucket = "acc-lifecycle"
+
+  rule {
+    id     = "backups"
+    status = "Enabled"
+  }
+}
+`,
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`At least one of expiration`),
+			},
+			{
+				Config: testAccProviderConfig(server) + `
+resource "vcd-object-storage-ext_bucket_lifecycle_configuration" "test" {
+  bucket = "acc-lifecycle"
+
+  rule {
+    id     = "backups"
+    status = "Enabled"
+
+    expiration {
+      days = 30
+      date = "2030-01-01"
+    }
+  }
+}
+`,
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`one \(and only one\)`),
+			},
+		},
+	})
+}
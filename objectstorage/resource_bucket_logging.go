@@ -0,0 +1,113 @@
+package objectstorage
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/josajunior81/terraform-provider-vcd-object-storage-ext/pkg"
+)
+
+type bucketLoggingResource struct {
+	bucketSubresource[bucketLoggingModel]
+}
+
+var (
+	_ resource.ResourceWithConfigure   = (*bucketLoggingResource)(nil)
+	_ resource.ResourceWithImportState = (*bucketLoggingResource)(nil)
+)
+
+type bucketLoggingModel struct {
+	Id                     types.String `tfsdk:"id"`
+	Bucket                 types.String `tfsdk:"bucket"`
+	TargetBucket           types.String `tfsdk:"target_bucket"`
+	TargetPrefix           types.String `tfsdk:"target_prefix"`
+	EnsureLogDeliveryGrant types.Bool   `tfsdk:"ensure_log_delivery_grant"`
+}
+
+func newBucketLoggingResource() resource.Resource {
+	r := &bucketLoggingResource{}
+	r.bucketSubresource = bucketSubresource[bucketLoggingModel]{name: "logging", ops: r}
+	return r
+}
+
+func (r *bucketLoggingResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_bucket_logging"
+}
+
+func (r *bucketLoggingResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Enables server access logging of a bucket. The access logs are delivered by the LogDelivery group (the SYSTEM-LOGGER grantee of bucket ACLs), which needs the WRITE and READ_ACP permissions on the target bucket.",
+		Attributes: bucketSubresourceAttributes(map[string]schema.Attribute{
+			"target_bucket": schema.StringAttribute{
+				Required:    true,
+				Description: "The bucket receiving the access logs. It can be the logged bucket itself.",
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"target_prefix": schema.StringAttribute{
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString(""),
+				Description: "Prefix of the keys of the access logs, to tell apart the logs of several buckets sharing a target bucket. Default \"\"",
+			},
+			"ensure_log_delivery_grant": schema.BoolAttribute{
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(false),
+				Description: "Adds the WRITE and READ_ACP grants of the LogDelivery group to the target bucket when missing, keeping its other grants. The grants are left in place on destroy. A target bucket managed with acl blocks should declare the SYSTEM-LOGGER grants there instead, or they show up as drift. Default false",
+			},
+		}),
+	}
+}
+
+// put grants the LogDelivery group access to the target bucket when asked,
+// then enables the logging, which the Object Storage refuses without the
+// grants.
+func (r *bucketLoggingResource) put(ctx context.Context, plan bucketLoggingModel, _ *diag.Diagnostics) error {
+	if plan.EnsureLogDeliveryGrant.ValueBool() {
+		if err := r.storage.EnsureLogDeliveryGrants(ctx, plan.TargetBucket.ValueString()); err != nil {
+			return err
+		}
+	}
+
+	logging := pkg.BucketLoggingStatus{LoggingEnabled: &pkg.LoggingEnabled{
+		TargetBucket: plan.TargetBucket.ValueString(),
+		TargetPrefix: plan.TargetPrefix.ValueString(),
+	}}
+
+	return r.storage.BucketLogging(ctx, plan.Bucket.ValueString(), logging)
+}
+
+// read sets the live logging of the bucket on model. The target bucket is
+// null when logging is disabled. ensure_log_delivery_grant is not stored by
+// the Object Storage and keeps its value, false once imported.
+func (r *bucketLoggingResource) read(ctx context.Context, model *bucketLoggingModel) (bool, error) {
+	logging, err := r.storage.GetBucketLogging(ctx, model.Bucket.ValueString())
+	if err != nil {
+		return false, err
+	}
+
+	model.TargetBucket = types.StringNull()
+	model.TargetPrefix = types.StringValue("")
+	if enabled := logging.LoggingEnabled; enabled != nil {
+		model.TargetBucket = types.StringValue(enabled.TargetBucket)
+		model.TargetPrefix = types.StringValue(enabled.TargetPrefix)
+	}
+	if model.EnsureLogDeliveryGrant.IsNull() {
+		model.EnsureLogDeliveryGrant = types.BoolValue(false)
+	}
+
+	return logging.LoggingEnabled != nil, nil
+}
+
+func (r *bucketLoggingResource) remove(ctx context.Context, bucket string) error {
+	return r.storage.BucketLogging(ctx, bucket, pkg.BucketLoggingStatus{})
+}
@@ -0,0 +1,116 @@
+package objectstorage
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccBucketLogging_basic(t *testing.T) {
+	server := testAccServer(t)
+
+	config := func(prefix string) string {
+		return testAccProviderConfig(server) + `
+resource "vcd-object-storage-ext_bucket" "logs" {
+  name       = "acc-logging-logs"
+  canned_acl = "private"
+}
+
+resource "vcd-object-storage-ext_bucket" "test" {
+  name = "acc-logging"
+}
+
+resource "vcd-object-storage-ext_bucket_logging" "test" {
+  bucket                    = vcd-object-storage-ext_bucket.test.name
+  target_bucket             = vcd-object-storage-ext_bucket.logs.name
+  target_prefix             = "` + prefix + `"
+  ensure_log_delivery_grant = true
+}
+`
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testCheckBucketsDestroyed(server),
+		Steps: []resource.TestStep{
+			{
+				Config: config("acc-logging/"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("vcd-object-storage-ext_bucket_logging.test", "id", "acc-logging"),
+					resource.TestCheckResourceAttr("vcd-object-storage-ext_bucket_logging.test", "target_bucket", "acc-logging-logs"),
+					resource.TestCheckResourceAttr("vcd-object-storage-ext_bucket_logging.test", "target_prefix", "acc-logging/"),
+				),
+			},
+			{
+				Config: config("logs/acc-logging/"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("vcd-object-storage-ext_bucket_logging.test", "target_prefix", "logs/acc-logging/"),
+				),
+			},
+			{
+				ResourceName:            "vcd-object-storage-ext_bucket_logging.test",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"ensure_log_delivery_grant"},
+			},
+		},
+	})
+}
+
+func TestAccBucketLogging_cannedAcl(t *testing.T) {
+	server := testAccServer(t)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testCheckBucketsDestroyed(server),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderConfig(server) + `
+resource "vcd-object-storage-ext_bucket" "logs" {
+  name       = "acc-logging-logs"
+  canned_acl = "log-delivery-write"
+}
+
+resource "vcd-object-storage-ext_bucket" "test" {
+  name = "acc-logging"
+}
+
+resource "vcd-object-storage-ext_bucket_logging" "test" {
+  bucket        = vcd-object-storage-ext_bucket.test.name
+  target_bucket = vcd-object-storage-ext_bucket.logs.name
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("vcd-object-storage-ext_bucket_logging.test", "target_prefix", ""),
+					resource.TestCheckResourceAttr("vcd-object-storage-ext_bucket_logging.test", "ensure_log_delivery_grant", "false"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccBucketLogging_missingGrant(t *testing.T) {
+	server := testAccServer(t)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testCheckBucketsDestroyed(server),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderConfig(server) + `
+resource "vcd-object-storage-ext_bucket" "logs" {
+  name       = "acc-logging-logs"
+  canned_acl = "private"
+}
+
+resource "vcd-object-storage-ext_bucket_logging" "test" {
+  bucket        = vcd-object-storage-ext_bucket.logs.name
+  target_bucket = vcd-object-storage-ext_bucket.logs.name
+}
+`,
+				ExpectError: regexp.MustCompile(`InvalidTargetBucketForLogging`),
+			},
+		},
+	})
+}
@@ -0,0 +1,70 @@
+package objectstorage
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework-jsontypes/jsontypes"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+type bucketPolicyResource struct {
+	bucketSubresource[bucketPolicyModel]
+}
+
+var (
+	_ resource.ResourceWithConfigure   = (*bucketPolicyResource)(nil)
+	_ resource.ResourceWithImportState = (*bucketPolicyResource)(nil)
+)
+
+type bucketPolicyModel struct {
+	Id     types.String         `tfsdk:"id"`
+	Bucket types.String         `tfsdk:"bucket"`
+	Policy jsontypes.Normalized `tfsdk:"policy"`
+}
+
+func newBucketPolicyResource() resource.Resource {
+	r := &bucketPolicyResource{}
+	r.bucketSubresource = bucketSubresource[bucketPolicyModel]{name: "policy", ops: r}
+	return r
+}
+
+func (r *bucketPolicyResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_bucket_policy"
+}
+
+func (r *bucketPolicyResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Attaches an IAM-style policy to a bucket, granting access beyond the grantees available to ACLs.",
+		Attributes: bucketSubresourceAttributes(map[string]schema.Attribute{
+			"policy": schema.StringAttribute{
+				Required:    true,
+				CustomType:  jsontypes.NormalizedType{},
+				Description: "The policy document in JSON. The key order and whitespace of the policy returned by the Object Storage are ignored.",
+			},
+		}),
+	}
+}
+
+func (r *bucketPolicyResource) put(ctx context.Context, plan bucketPolicyModel, _ *diag.Diagnostics) error {
+	return r.storage.BucketPolicy(ctx, plan.Bucket.ValueString(), plan.Policy.ValueString())
+}
+
+// read sets the live policy of the bucket on model. The document is stored
+// as returned; semantic equality keeps the configured formatting in state.
+func (r *bucketPolicyResource) read(ctx context.Context, model *bucketPolicyModel) (bool, error) {
+	policy, err := r.storage.GetBucketPolicy(ctx, model.Bucket.ValueString())
+	if err != nil {
+		return false, err
+	}
+
+	model.Policy = jsontypes.NewNormalizedValue(policy)
+
+	return policy != "", nil
+}
+
+func (r *bucketPolicyResource) remove(ctx context.Context, bucket string) error {
+	return r.storage.BucketPolicy(ctx, bucket, "")
+}
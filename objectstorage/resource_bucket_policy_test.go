@@ -0,0 +1,80 @@
+package objectstorage
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccBucketPolicy_basic(t *testing.T) {
+	server := testAccServer(t)
+
+	config := func(action string) string {
+		return testAccProviderConfig(server) + `
+resource "vcd-object-storage-ext_bucket" "test" {
+  name = "acc-policy"
+}
+
+resource "vcd-object-storage-ext_bucket_policy" "test" {
+  bucket = vcd-object-storage-ext_bucket.test.name
+  policy = jsonencode({
+    Version = "2012-10-17"
+    Statement = [{
+      Sid       = "PublicRead"
+      Effect    = "Allow"
+      Principal = "*"
+      Action    = ["` + action + `"]
+      Resource  = ["arn:aws:s3:::acc-policy/*"]
+    }]
+  })
+}
+`
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testCheckBucketsDestroyed(server),
+		Steps: []resource.TestStep{
+			{
+				Config: config("s3:GetObject"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("vcd-object-storage-ext_bucket_policy.test", "id", "acc-policy"),
+					resource.TestMatchResourceAttr("vcd-object-storage-ext_bucket_policy.test", "policy", regexp.MustCompile(`s3:GetObject`)),
+				),
+			},
+			{
+				Config: config("s3:ListBucket"),
+				Check:  resource.TestMatchResourceAttr("vcd-object-storage-ext_bucket_policy.test", "policy", regexp.MustCompile(`s3:ListBucket`)),
+			},
+			{
+				ResourceName:      "vcd-object-storage-ext_bucket_policy.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+				// The imported policy has the formatting of the Object
+				// Storage, which only matches the configuration semantically.
+				ImportStateVerifyIgnore: []string{"policy"},
+			},
+		},
+	})
+}
+
+func TestAccBucketPolicy_invalidJSON(t *testing.T) {
+	server := testAccServer(t)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderConfig(server) + `
+resource "vcd-object-storage-ext_bucket_policy" "test" {
+  bucket = "acc-policy"
+  policy = "{\"Version\": "
+}
+`,
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`not valid JSON`),
+			},
+		},
+	})
+}
@@ -0,0 +1,116 @@
+package objectstorage
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/josajunior81/terraform-provider-vcd-object-storage-ext/pkg"
+)
+
+type bucketServerSideEncryptionResource struct {
+	bucketSubresource[bucketServerSideEncryptionModel]
+}
+
+var (
+	_ resource.ResourceWithConfigure      = (*bucketServerSideEncryptionResource)(nil)
+	_ resource.ResourceWithImportState    = (*bucketServerSideEncryptionResource)(nil)
+	_ resource.ResourceWithValidateConfig = (*bucketServerSideEncryptionResource)(nil)
+)
+
+type bucketServerSideEncryptionModel struct {
+	Id             types.String `tfsdk:"id"`
+	Bucket         types.String `tfsdk:"bucket"`
+	SSEAlgorithm   types.String `tfsdk:"sse_algorithm"`
+	KMSMasterKeyId types.String `tfsdk:"kms_master_key_id"`
+}
+
+func newBucketServerSideEncryptionResource() resource.Resource {
+	r := &bucketServerSideEncryptionResource{}
+	r.bucketSubresource = bucketSubresource[bucketServerSideEncryptionModel]{name: "encryption", ops: r}
+	return r
+}
+
+func (r *bucketServerSideEncryptionResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_bucket_server_side_encryption"
+}
+
+func (r *bucketServerSideEncryptionResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Sets the default encryption of the objects stored in a bucket without encryption headers. Objects already stored are not re-encrypted. SSE-C keys are given with every request and cannot be a bucket default.",
+		Attributes: bucketSubresourceAttributes(map[string]schema.Attribute{
+			"sse_algorithm": schema.StringAttribute{
+				Required:    true,
+				Description: "Encryption algorithm, AES256 for keys managed by the Object Storage (SSE-S3) or aws:kms for keys of the KMS configured in the Object Storage. Valid Values: AES256 | aws:kms",
+				Validators: []validator.String{
+					stringvalidator.OneOf(pkg.SSEAlgorithmAES256, pkg.SSEAlgorithmKMS),
+				},
+			},
+			"kms_master_key_id": schema.StringAttribute{
+				Optional:    true,
+				Description: "Id of the KMS key, with the aws:kms algorithm. The default KMS key of the Object Storage is used when not set",
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+		}),
+	}
+}
+
+// ValidateConfig rejects a KMS key with the AES256 algorithm, which the
+// Object Storage would only refuse during apply.
+func (r *bucketServerSideEncryptionResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config bucketServerSideEncryptionModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if config.KMSMasterKeyId.IsNull() || config.SSEAlgorithm.IsUnknown() || config.SSEAlgorithm.ValueString() == pkg.SSEAlgorithmKMS {
+		return
+	}
+
+	resp.Diagnostics.AddAttributeError(path.Root("kms_master_key_id"), "Invalid server-side encryption",
+		"kms_master_key_id can only be set with the aws:kms sse_algorithm.")
+}
+
+func (r *bucketServerSideEncryptionResource) put(ctx context.Context, plan bucketServerSideEncryptionModel, _ *diag.Diagnostics) error {
+	encryption := pkg.ServerSideEncryptionConfiguration{Rules: []pkg.ServerSideEncryptionRule{{
+		ApplyServerSideEncryptionByDefault: pkg.ServerSideEncryptionByDefault{
+			SSEAlgorithm:   plan.SSEAlgorithm.ValueString(),
+			KMSMasterKeyID: plan.KMSMasterKeyId.ValueString(),
+		},
+	}}}
+
+	return r.storage.BucketEncryption(ctx, plan.Bucket.ValueString(), encryption)
+}
+
+// read sets the live default encryption of the bucket on model. The
+// algorithm is null when the bucket has no default encryption.
+func (r *bucketServerSideEncryptionResource) read(ctx context.Context, model *bucketServerSideEncryptionModel) (bool, error) {
+	rules, err := r.storage.GetBucketEncryption(ctx, model.Bucket.ValueString())
+	if err != nil {
+		return false, err
+	}
+
+	model.SSEAlgorithm = types.StringNull()
+	model.KMSMasterKeyId = types.StringNull()
+	if len(rules) > 0 {
+		byDefault := rules[0].ApplyServerSideEncryptionByDefault
+		model.SSEAlgorithm = types.StringValue(byDefault.SSEAlgorithm)
+		if byDefault.KMSMasterKeyID != "" {
+			model.KMSMasterKeyId = types.StringValue(byDefault.KMSMasterKeyID)
+		}
+	}
+
+	return len(rules) > 0, nil
+}
+
+func (r *bucketServerSideEncryptionResource) remove(ctx context.Context, bucket string) error {
+	return r.storage.BucketEncryption(ctx, bucket, pkg.ServerSideEncryptionConfiguration{})
+}
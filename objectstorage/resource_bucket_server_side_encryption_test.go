@@ -0,0 +1,89 @@
+package objectstorage
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/josajunior81/terraform-provider-vcd-object-storage-ext/pkg"
+)
+
+func TestAccBucketServerSideEncryption_basic(t *testing.T) {
+	server := testAccServer(t)
+
+	config := func(encryption string) string {
+		return testAccProviderConfig(server) + `
+resource "vcd-object-storage-ext_bucket" "test" {
+  name = "acc-encryption"
+}
+
+resource "vcd-object-storage-ext_bucket_server_side_encryption" "test" {
+  bucket = vcd-object-storage-ext_bucket.test.name
+  ` + encryption + `
+}
+`
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testCheckBucketsDestroyed(server),
+		Steps: []resource.TestStep{
+			{
+				Config: config(`sse_algorithm = "AES256"`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("vcd-object-storage-ext_bucket_server_side_encryption.test", "id", "acc-encryption"),
+					resource.TestCheckResourceAttr("vcd-object-storage-ext_bucket_server_side_encryption.test", "sse_algorithm", "AES256"),
+					resource.TestCheckNoResourceAttr("vcd-object-storage-ext_bucket_server_side_encryption.test", "kms_master_key_id"),
+				),
+			},
+			{
+				Config: config(`sse_algorithm = "aws:kms"
+  kms_master_key_id = "backup-key"`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("vcd-object-storage-ext_bucket_server_side_encryption.test", "sse_algorithm", "aws:kms"),
+					resource.TestCheckResourceAttr("vcd-object-storage-ext_bucket_server_side_encryption.test", "kms_master_key_id", "backup-key"),
+				),
+			},
+			{
+				ResourceName:      "vcd-object-storage-ext_bucket_server_side_encryption.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				// Removing the default encryption outside of Terraform is
+				// detected and planned to be restored.
+				PreConfig: func() {
+					client := pkg.NewS3ClientWithKeys(server.URL, "", "osetest", "osetest", "")
+					if err := client.BucketEncryption(context.Background(), "acc-encryption", pkg.ServerSideEncryptionConfiguration{}); err != nil {
+						t.Fatal(err)
+					}
+				},
+				Config:             config(`sse_algorithm = "AES256"`),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
+
+func TestAccBucketServerSideEncryption_invalidConfiguration(t *testing.T) {
+	server := testAccServer(t)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderConfig(server) + `
+resource "vcd-object-storage-ext_bucket_server_side_encryption" "test" {
+  bucket            = "acc-encryption"
+  sse_algorithm     = "AES256"
+  kms_master_key_id = "backup-key"
+}
+`,
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`only be set with the aws:kms`),
+			},
+		},
+	})
+}
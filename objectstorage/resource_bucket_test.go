@@ -0,0 +1,261 @@
+package objectstorage
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/josajunior81/terraform-provider-vcd-object-storage-ext/pkg"
+)
+
+func TestAccBucket_basic(t *testing.T) {
+	testBackends(t, testAccBucketBasic)
+}
+
+func testAccBucketBasic(t *testing.T, backend testBackend) {
+	backend.test(t, resource.TestCase{
+		Steps: []resource.TestStep{
+			{
+				Config: backend.providerConfig + `
+resource "vcd-object-storage-ext_bucket" "test" {
+  name = "acc-bucket"
+
+  tag {
+    name  = "env"
+    value = "test"
+  }
+
+  acl {
+    user       = "PUBLIC"
+    permission = "READ"
+  }
+
+  cors {
+    allowed_methods = ["GET", "PUT"]
+    allowed_origins = ["https://*.example.com"]
+    max_age_seconds = 600
+  }
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testCheckBucketExists(backend.storage, "acc-bucket"),
+					resource.TestCheckResourceAttr("vcd-object-storage-ext_bucket.test", "id", "acc-bucket"),
+					resource.TestCheckResourceAttr("vcd-object-storage-ext_bucket.test", "tag.#", "1"),
+					resource.TestCheckResourceAttr("vcd-object-storage-ext_bucket.test", "tag.0.value", "test"),
+					resource.TestCheckResourceAttr("vcd-object-storage-ext_bucket.test", "acl.#", "1"),
+					resource.TestCheckResourceAttr("vcd-object-storage-ext_bucket.test", "acl.0.user", "PUBLIC"),
+					resource.TestCheckResourceAttr("vcd-object-storage-ext_bucket.test", "cors.0.allowed_methods.#", "2"),
+					resource.TestCheckResourceAttr("vcd-object-storage-ext_bucket.test", "cors.0.max_age_seconds", "600"),
+				),
+			},
+			{
+				Config: backend.providerConfig + `
+resource "vcd-object-storage-ext_bucket" "test" {
+  name       = "acc-bucket"
+  canned_acl = "private"
+
+  tag {
+    name  = "env"
+    value = "prod"
+  }
+
+  tag {
+    name  = "team"
+    value = "storage"
+  }
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("vcd-object-storage-ext_bucket.test", "tag.#", "2"),
+					resource.TestCheckResourceAttr("vcd-object-storage-ext_bucket.test", "tag.0.value", "prod"),
+					resource.TestCheckResourceAttr("vcd-object-storage-ext_bucket.test", "cors.#", "0"),
+				),
+			},
+			{
+				ResourceName:            "vcd-object-storage-ext_bucket.test",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"canned_acl"},
+			},
+		},
+	})
+}
+
+func TestAccBucket_invalidConfiguration(t *testing.T) {
+	server := testAccServer(t)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderConfig(server) + `
+resource "vcd-object-storage-ext_bucket" "test" {
+  name       = "acc-bucket"
+  canned_acl = "private"
+
+  acl {
+    user       = "PUBLIC"
+    permission = "READ"
+  }
+}
+`,
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`conflicts with`),
+			},
+			{
+				Config: testAccProviderConfig(server) + `
+resource "vcd-object-storage-ext_bucket" "test" {
+  name = "acc-bucket"
+
+  cors {
+    allowed_methods = ["PATCH"]
+    allowed_origins = ["https://*.*.example.com"]
+  }
+}
+`,
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`value must be one of`),
+			},
+		},
+	})
+
+	if buckets := server.Buckets(); len(buckets) != 0 {
+		t.Errorf("invalid configurations created buckets %v", buckets)
+	}
+}
+
+func TestBucket_duplicateAcl(t *testing.T) {
+	storage := pkg.NewMemoryStorage("")
+
+	resource.UnitTest(t, resource.TestCase{
+		PreCheck:                 func() { testUnitPreCheck(t) },
+		ProtoV5ProviderFactories: protoV5ProviderFactories(storage),
+		Steps: []resource.TestStep{
+			{
+				Config: `
+resource "vcd-object-storage-ext_bucket" "test" {
+  name = "unit-bucket"
+
+  acl {
+    user       = "PUBLIC"
+    permission = "READ"
+  }
+
+  acl {
+    user       = "PUBLIC"
+    permission = "READ"
+  }
+}
+`,
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`user PUBLIC is granted READ more than once`),
+			},
+			{
+				Config: `
+resource "vcd-object-storage-ext_bucket" "test" {
+  name = "unit-bucket"
+
+  acl {
+    user       = "PUBLIC"
+    permission = "READ"
+  }
+
+  acl {
+    user       = "AUTHENTICATED"
+    permission = "READ"
+  }
+}
+`,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+
+	if buckets := storage.Buckets(); len(buckets) != 0 {
+		t.Errorf("planning created buckets %v", buckets)
+	}
+}
+
+// bucketLister is a storage whose buckets the tests can list, either the
+// emulator or pkg.MemoryStorage.
+type bucketLister interface {
+	Buckets() []string
+}
+
+func testCheckBucketExists(storage bucketLister, name string) resource.TestCheckFunc {
+	return func(*terraform.State) error {
+		if !slices.Contains(storage.Buckets(), name) {
+			return fmt.Errorf("bucket %s not found, buckets are %v", name, storage.Buckets())
+		}
+		return nil
+	}
+}
+
+func testCheckBucketsDestroyed(storage bucketLister) resource.TestCheckFunc {
+	return func(*terraform.State) error {
+		if buckets := storage.Buckets(); len(buckets) != 0 {
+			return fmt.Errorf("buckets %v still exist", buckets)
+		}
+		return nil
+	}
+}
+
+func TestBucketUpgradeStateV0(t *testing.T) {
+	ctx := context.Background()
+	r := &bucketResource{}
+	upgrader := r.UpgradeState(ctx)[0]
+
+	prior := tfsdk.State{
+		Schema: *upgrader.PriorSchema,
+		Raw:    tftypes.NewValue(upgrader.PriorSchema.Type().TerraformType(ctx), nil),
+	}
+	for name, value := range map[string]string{"id": "1c9a36f4-7e0c-4c5e-9a2b-0f8d3c6b5a41", "name": "my-bucket", "canned_acl": "private"} {
+		if diags := prior.SetAttribute(ctx, path.Root(name), value); diags.HasError() {
+			t.Fatal(diags)
+		}
+	}
+
+	var schemaResp fwresource.SchemaResponse
+	r.Schema(ctx, fwresource.SchemaRequest{}, &schemaResp)
+	resp := fwresource.UpgradeStateResponse{State: tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+	}}
+	upgrader.StateUpgrader(ctx, fwresource.UpgradeStateRequest{State: &prior}, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatal(resp.Diagnostics)
+	}
+
+	var got bucketModel
+	if diags := resp.State.Get(ctx, &got); diags.HasError() {
+		t.Fatal(diags)
+	}
+	if got.Id.ValueString() != "my-bucket" || got.Name.ValueString() != "my-bucket" || got.CannedAcl.ValueString() != "private" {
+		t.Errorf("upgraded state = %+v", got)
+	}
+}
+
+func TestBucketSchemaV0(t *testing.T) {
+	ctx := context.Background()
+	priorSchema := bucketSchemaV0()
+
+	var got []string
+	for name := range priorSchema.Type().TerraformType(ctx).(tftypes.Object).AttributeTypes {
+		got = append(got, name)
+	}
+	slices.Sort(got)
+
+	wanted := []string{"acl", "canned_acl", "cors", "id", "last_updated", "name", "tag"}
+	if !slices.Equal(got, wanted) {
+		t.Errorf("version 0 attributes = %v, want %v", got, wanted)
+	}
+}
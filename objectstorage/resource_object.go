@@ -1,105 +1,389 @@
 package objectstorage
 
 import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
 	"log"
+	"os"
+	"strings"
+	"time"
 
-	"github.com/google/uuid"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/josajunior81/terraform-provider-vcd-object-storage-ext/pkg"
 )
 
 func resourceObject() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceObjectCreate,
-		Read:   resourceObjectRead,
-		Update: resourceObjectUpdate,
-		Delete: resourceObjectDelete,
+		CreateContext: resourceObjectCreate,
+		ReadContext:   resourceObjectRead,
+		UpdateContext: resourceObjectUpdate,
+		DeleteContext: resourceObjectDelete,
+		CustomizeDiff: resourceObjectCustomizeDiff,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceObjectImport,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(30 * time.Minute),
+			Update: schema.DefaultTimeout(30 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
+
+		SchemaVersion: 1,
+		StateUpgraders: []schema.StateUpgrader{
+			{
+				Version: 0,
+				Type:    resourceObjectV0().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourceObjectStateUpgradeV0,
+			},
+		},
+
+		Schema: resourceObjectSchema(),
+	}
+}
+
+func resourceObjectSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"last_updated": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Deprecated:  "last_updated is never set and will be removed in the next major version.",
+			Description: "Never set.",
+		},
+
+		"bucket": {
+			Type:     schema.TypeString,
+			Required: true,
+			ForceNew: true,
+		},
+
+		"key": {
+			Type:     schema.TypeString,
+			Required: true,
+			ForceNew: true,
+		},
+
+		"source": {
+			Type:             schema.TypeString,
+			Required:         true,
+			DiffSuppressFunc: suppressImportedSource,
+		},
+
+		"overwrite": {
+			Type:     schema.TypeBool,
+			Computed: false,
+			Optional: true,
+			Default:  true,
+		},
+
+		"delete_all_versions": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "If set, destroying the resource removes every version of the object instead of leaving a delete marker on versioned buckets. Default false",
+		},
+
+		"source_hash": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "Triggers an upload when changed, e.g. filemd5(source). Needed to track the content of objects uploaded in parts or encrypted with SSE-KMS, whose etag is not the MD5 of the file.",
+		},
+
+		"etag": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The entity tag of the object. It is the MD5 of the content for objects uploaded in a single request and not encrypted with SSE-KMS.",
+		},
+
+		"server_side_encryption": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The algorithm the object is encrypted with at rest, AES256 or aws:kms, taken from the default encryption of the bucket.",
+		},
+
+		"size": {
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "The size of the object in bytes.",
+		},
 
+		"content_type": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The content type of the object.",
+		},
+
+		"last_modified": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "When the object was last modified, in RFC 3339 format.",
+		},
+	}
+}
+
+// resourceObjectV0 is the schema of version 0, frozen so the state upgrade
+// keeps decoding old states when the current schema changes.
+func resourceObjectV0() *schema.Resource {
+	return &schema.Resource{
 		Schema: map[string]*schema.Schema{
 			"last_updated": {
 				Type:     schema.TypeString,
 				Computed: true,
 			},
-
 			"bucket": {
 				Type:     schema.TypeString,
 				Required: true,
 			},
-
 			"key": {
 				Type:     schema.TypeString,
 				Required: true,
 			},
-
 			"source": {
 				Type:     schema.TypeString,
 				Required: true,
 			},
-
 			"overwrite": {
 				Type:     schema.TypeBool,
-				Computed: false,
 				Optional: true,
 				Default:  true,
 			},
-
-			// "tag": {
-			// 	Type:     schema.TypeList,
-			// 	Optional: true,
-			// 	ForceNew: false,
-			// 	Elem: &schema.Resource{
-			// 		Schema: map[string]*schema.Schema{
-			// 			"name": {
-			// 				Type:     schema.TypeString,
-			// 				Required: true,
-			// 			},
-			// 			"value": {
-			// 				Type:     schema.TypeString,
-			// 				Required: true,
-			// 			},
-			// 		},
-			// 	},
-			// },
 		},
 	}
 }
 
-// Creates Bucket on the Object Storage
-func resourceObjectCreate(d *schema.ResourceData, meta interface{}) error {
-	s3client := meta.(pkg.S3Client)
+// resourceObjectStateUpgradeV0 replaces the random UUID ID of version 0 with
+// bucket/key.
+func resourceObjectStateUpgradeV0(_ context.Context, rawState map[string]interface{}, _ interface{}) (map[string]interface{}, error) {
+	if rawState == nil {
+		return rawState, nil
+	}
+
+	rawState["id"] = fmt.Sprintf("%v/%v", rawState["bucket"], rawState["key"])
+
+	return rawState, nil
+}
+
+// Uploads the Object source to its bucket
+func resourceObjectCreate(c context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	s3client := meta.(pkg.ObjectStorage)
+
+	var diags diag.Diagnostics
 
-	d.SetId(uuid.NewString())
 	bucket := d.Get("bucket").(string)
 	key := d.Get("key").(string)
 	source := d.Get("source").(string)
 	overwrite := d.Get("overwrite").(bool)
 
-	s3client.UploadObject(bucket, key, source, overwrite)
+	if err := s3client.UploadObject(c, bucket, key, source, overwrite); err != nil {
+		return append(diags, errorDiagnostic("Error uploading object", err))
+	}
 
-	return resourceObjectRead(d, meta)
+	d.SetId(bucket + "/" + key)
+
+	return resourceObjectRead(c, d, meta)
 }
 
-// Reads Bucket from Object Storage
-func resourceObjectRead(d *schema.ResourceData, meta interface{}) error {
-	resourceID := d.Id()
-	log.Println(">>> resourceID:", resourceID)
-	return nil
+// Imports an Object from its bucket/key ID
+func resourceObjectImport(_ context.Context, d *schema.ResourceData, _ interface{}) ([]*schema.ResourceData, error) {
+	for k, v := range map[string]interface{}{
+		"overwrite":           true,
+		"delete_all_versions": false,
+	} {
+		if err := d.Set(k, v); err != nil {
+			return nil, err
+		}
+	}
+
+	return []*schema.ResourceData{d}, nil
 }
 
-func resourceObjectUpdate(d *schema.ResourceData, meta interface{}) error {
-	// resourceID := d.Id()
-	s3client := meta.(pkg.S3Client)
+// parseObjectId splits an object ID into bucket and key. The key may contain
+// slashes, the bucket name cannot.
+func parseObjectId(id string) (string, string, error) {
+	bucket, key, ok := strings.Cut(id, "/")
+	if !ok || bucket == "" || key == "" {
+		return "", "", fmt.Errorf("unexpected format of ID %q, expected bucket/key", id)
+	}
+	return bucket, key, nil
+}
 
-	bucketName := d.Get("name").(string)
-	tags := d.Get("tag").([]any)
+// Reads Object metadata from Object Storage
+func resourceObjectRead(c context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	s3client := meta.(pkg.ObjectStorage)
 
-	s3client.BucketTags(bucketName, tags)
-	return nil
+	var diags diag.Diagnostics
+
+	bucket, key, err := parseObjectId(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	info, err := s3client.HeadObject(c, bucket, key)
+	if pkg.IsNotFound(err) {
+		log.Printf("[WARN] Object %s/%s not found, removing from state", bucket, key)
+		d.SetId("")
+		return diags
+	}
+	if err != nil {
+		return append(diags, errorDiagnostic("Error reading object", err))
+	}
+
+	if err := d.Set("bucket", bucket); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("key", key); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("etag", info.ETag); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("size", int(info.Size)); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("content_type", info.ContentType); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("server_side_encryption", info.ServerSideEncryption); err != nil {
+		return diag.FromErr(err)
+	}
+	lastModified := ""
+	if !info.LastModified.IsZero() {
+		lastModified = info.LastModified.Format(time.RFC3339)
+	}
+	if err := d.Set("last_modified", lastModified); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return diags
 }
 
-// Deletes Bucket at the Object Storage
-func resourceObjectDelete(d *schema.ResourceData, meta interface{}) error {
-	resourceID := d.Id()
-	log.Println(">>> resourceID:", resourceID)
+// resourceObjectCustomizeDiff plans an upload when the local source no longer
+// matches the stored object, whether the file was edited or the object was
+// overwritten remotely. Objects uploaded in parts or encrypted with SSE-KMS
+// have an etag that is not the MD5 of the file, they are tracked through
+// source_hash instead.
+func resourceObjectCustomizeDiff(_ context.Context, d *schema.ResourceDiff, _ interface{}) error {
+	if d.Id() == "" || d.HasChange("source") {
+		return nil
+	}
+
+	object := pkg.ObjectInfo{ETag: d.Get("etag").(string), ServerSideEncryption: d.Get("server_side_encryption").(string)}
+	if sourceDiffers(object, configuredSource(d.GetRawConfig(), d.Get("source").(string))) {
+		return d.SetNewComputed("etag")
+	}
+
 	return nil
 }
+
+// sourceDiffers reports whether the file at source no longer matches object.
+// Objects whose etag is not the MD5 of their content never differ.
+func sourceDiffers(object pkg.ObjectInfo, source string) bool {
+	if !object.ETagIsMD5() {
+		return false
+	}
+
+	sum, err := fileMD5(source)
+	if err != nil {
+		log.Printf("[WARN] Unable to hash object source: %s", err)
+		return false
+	}
+
+	if sum != object.ETag {
+		log.Printf("[DEBUG] Object source MD5 %s differs from etag %s", sum, object.ETag)
+		return true
+	}
+
+	return false
+}
+
+// suppressImportedSource hides the source of an imported object, which is
+// unknown, as long as the configured file still matches the stored object.
+// Any other change plans an upload.
+func suppressImportedSource(_, old, new string, d *schema.ResourceData) bool {
+	if old != "" || d.Id() == "" {
+		return false
+	}
+
+	object := pkg.ObjectInfo{ETag: d.Get("etag").(string), ServerSideEncryption: d.Get("server_side_encryption").(string)}
+	if !object.ETagIsMD5() {
+		return false
+	}
+
+	sum, err := fileMD5(new)
+	return err == nil && sum == object.ETag
+}
+
+// configuredSource returns the source set in the configuration, which is
+// missing from the state of imported objects, or fallback when it is unknown.
+func configuredSource(config cty.Value, fallback string) string {
+	if config.IsNull() || !config.IsKnown() {
+		return fallback
+	}
+	source := config.GetAttr("source")
+	if source.IsNull() || !source.IsKnown() {
+		return fallback
+	}
+	return source.AsString()
+}
+
+func fileMD5(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := md5.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func resourceObjectUpdate(c context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	s3client := meta.(pkg.ObjectStorage)
+
+	var diags diag.Diagnostics
+
+	bucket := d.Get("bucket").(string)
+	key := d.Get("key").(string)
+	source := configuredSource(d.GetRawConfig(), d.Get("source").(string))
+
+	// The diff applied here is computed again without CustomizeDiff, so the
+	// etag planned as unknown does not show up as a change: the source is
+	// compared with the stored object once more. The object is managed by
+	// this resource, so it is always replaced.
+	object := pkg.ObjectInfo{ETag: d.Get("etag").(string), ServerSideEncryption: d.Get("server_side_encryption").(string)}
+	if d.HasChanges("source", "source_hash") || sourceDiffers(object, source) {
+		if err := s3client.UploadObject(c, bucket, key, source, true); err != nil {
+			return append(diags, errorDiagnostic("Error uploading object", err))
+		}
+		if err := d.Set("source", source); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	return resourceObjectRead(c, d, meta)
+}
+
+// Deletes Object at the Object Storage
+func resourceObjectDelete(c context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	s3client := meta.(pkg.ObjectStorage)
+
+	var diags diag.Diagnostics
+
+	bucket := d.Get("bucket").(string)
+	key := d.Get("key").(string)
+
+	if err := s3client.DeleteObject(c, bucket, key, d.Get("delete_all_versions").(bool)); err != nil && !pkg.IsNotFound(err) {
+		return append(diags, errorDiagnostic("Error deleting object", err))
+	}
+
+	return diags
+}
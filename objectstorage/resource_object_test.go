@@ -0,0 +1,229 @@
+package objectstorage
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/josajunior81/terraform-provider-vcd-object-storage-ext/pkg"
+)
+
+func TestAccObject_basic(t *testing.T) {
+	testBackends(t, testAccObjectBasic)
+}
+
+func testAccObjectBasic(t *testing.T, backend testBackend) {
+	source := filepath.Join(t.TempDir(), "hello.txt")
+	write := writeSource(t, source)
+	write("hello")()
+
+	config := backend.providerConfig + fmt.Sprintf(`
+resource "vcd-object-storage-ext_bucket" "test" {
+  name = "acc-objects"
+}
+
+resource "vcd-object-storage-ext_object" "test" {
+  bucket = vcd-object-storage-ext_bucket.test.name
+  key    = "dir/hello.txt"
+  source = %q
+}
+`, source)
+
+	backend.test(t, resource.TestCase{
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testCheckObjectContent(backend.object, "acc-objects", "dir/hello.txt", "hello"),
+					resource.TestCheckResourceAttr("vcd-object-storage-ext_object.test", "id", "acc-objects/dir/hello.txt"),
+					resource.TestCheckResourceAttr("vcd-object-storage-ext_object.test", "etag", md5Hex("hello")),
+					resource.TestCheckResourceAttr("vcd-object-storage-ext_object.test", "size", "5"),
+					resource.TestCheckResourceAttrSet("vcd-object-storage-ext_object.test", "last_modified"),
+				),
+			},
+			{
+				// Editing the file plans an upload through the etag.
+				PreConfig: write("hello again"),
+				Config:    config,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testCheckObjectContent(backend.object, "acc-objects", "dir/hello.txt", "hello again"),
+					resource.TestCheckResourceAttr("vcd-object-storage-ext_object.test", "etag", md5Hex("hello again")),
+				),
+			},
+			{
+				ResourceName:            "vcd-object-storage-ext_object.test",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"source"},
+			},
+		},
+	})
+}
+
+func TestAccObject_import(t *testing.T) {
+	server := testAccServer(t)
+
+	source := filepath.Join(t.TempDir(), "hello.txt")
+	write := writeSource(t, source)
+	write("hello")()
+
+	bucketConfig := testAccProviderConfig(server) + `
+resource "vcd-object-storage-ext_bucket" "test" {
+  name = "acc-import"
+}
+`
+	config := bucketConfig + fmt.Sprintf(`
+resource "vcd-object-storage-ext_object" "test" {
+  bucket = vcd-object-storage-ext_bucket.test.name
+  key    = "hello.txt"
+  source = %q
+}
+`, source)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testCheckBucketsDestroyed(server),
+		Steps: []resource.TestStep{
+			{
+				Config: bucketConfig,
+			},
+			{
+				// The object was uploaded outside of Terraform.
+				PreConfig: func() {
+					client := pkg.NewS3ClientWithKeys(server.URL, "us-east-1", "osetest", "osetest", "")
+					if err := client.UploadObject(context.Background(), "acc-import", "hello.txt", source, true); err != nil {
+						t.Fatal(err)
+					}
+				},
+				Config:             config,
+				ResourceName:       "vcd-object-storage-ext_object.test",
+				ImportState:        true,
+				ImportStateId:      "acc-import/hello.txt",
+				ImportStatePersist: true,
+			},
+			{
+				// The source is not imported, the first plan is clean
+				// while the file matches the object.
+				Config:   config,
+				PlanOnly: true,
+			},
+			{
+				PreConfig: write("hello again"),
+				Config:    config,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testCheckObjectContent(server.Object, "acc-import", "hello.txt", "hello again"),
+					resource.TestCheckResourceAttr("vcd-object-storage-ext_object.test", "source", source),
+					resource.TestCheckResourceAttr("vcd-object-storage-ext_object.test", "etag", md5Hex("hello again")),
+				),
+			},
+		},
+	})
+}
+
+func TestAccObject_kmsEncrypted(t *testing.T) {
+	server := testAccServer(t)
+
+	source := filepath.Join(t.TempDir(), "secret.txt")
+	writeSource(t, source)("secret")()
+
+	config := testAccProviderConfig(server) + fmt.Sprintf(`
+resource "vcd-object-storage-ext_bucket" "test" {
+  name = "acc-kms"
+}
+
+resource "vcd-object-storage-ext_bucket_server_side_encryption" "test" {
+  bucket        = vcd-object-storage-ext_bucket.test.name
+  sse_algorithm = "aws:kms"
+}
+
+resource "vcd-object-storage-ext_object" "test" {
+  bucket = vcd-object-storage-ext_bucket_server_side_encryption.test.bucket
+  key    = "secret.txt"
+  source = %q
+}
+`, source)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testCheckBucketsDestroyed(server),
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testCheckObjectContent(server.Object, "acc-kms", "secret.txt", "secret"),
+					resource.TestCheckResourceAttr("vcd-object-storage-ext_object.test", "server_side_encryption", "aws:kms"),
+				),
+			},
+			{
+				// The etag of a KMS encrypted object is not the MD5 of
+				// the source, which must not plan an upload.
+				Config:   config,
+				PlanOnly: true,
+			},
+		},
+	})
+}
+
+func md5Hex(content string) string {
+	sum := md5.Sum([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// writeSource returns a function building a PreConfig that writes content to
+// path.
+func writeSource(t *testing.T, path string) func(content string) func() {
+	return func(content string) func() {
+		return func() {
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+// testCheckObjectContent checks the content of the object read by object, the
+// Object method of the emulator or of the backend under test.
+func testCheckObjectContent(object func(bucket, key string) ([]byte, bool), bucket, key, content string) resource.TestCheckFunc {
+	return func(*terraform.State) error {
+		data, ok := object(bucket, key)
+		if !ok {
+			return fmt.Errorf("object %s/%s not found", bucket, key)
+		}
+		if !bytes.Equal(data, []byte(content)) {
+			return fmt.Errorf("object %s/%s is %q, want %q", bucket, key, data, content)
+		}
+		return nil
+	}
+}
+
+func TestResourceObjectStateUpgradeV0(t *testing.T) {
+	tests := []struct {
+		name          string
+		state, wanted map[string]interface{}
+	}{
+		{
+			"uuid id",
+			map[string]interface{}{"id": "1c9a36f4-7e0c-4c5e-9a2b-0f8d3c6b5a41", "bucket": "my-bucket", "key": "dir/hello.txt", "source": "hello.txt"},
+			map[string]interface{}{"id": "my-bucket/dir/hello.txt", "bucket": "my-bucket", "key": "dir/hello.txt", "source": "hello.txt"},
+		},
+		{"nil state", nil, nil},
+	}
+
+	for _, test := range tests {
+		got, err := resourceObjectStateUpgradeV0(context.Background(), test.state, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if !reflect.DeepEqual(got, test.wanted) {
+			t.Errorf("%s: upgraded state = %v, want %v", test.name, got, test.wanted)
+		}
+	}
+}
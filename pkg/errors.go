@@ -0,0 +1,118 @@
+package pkg
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrInvalidUrl is returned by NewS3Client when the VCD url cannot be parsed.
+	ErrInvalidUrl = errors.New("invalid VCD url")
+	// ErrAuthentication is returned by NewS3Client when the API token cannot
+	// be exchanged for a bearer token.
+	ErrAuthentication = errors.New("unable to authenticate with VCD")
+)
+
+// APIError is returned for every non-2xx response of the Object Storage Extension
+// or of an S3 endpoint.
+type APIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+	RequestId  string
+	Method     string
+	Url        string
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s %s: %d %s", e.Method, e.Url, e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Code != "" {
+		msg += " (" + e.Code + ")"
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.RequestId != "" {
+		msg += " [request id " + e.RequestId + "]"
+	}
+	return msg
+}
+
+// oseErrorBody is the JSON error document returned by the OSE API.
+type oseErrorBody struct {
+	Code      string `json:"code"`
+	ErrorCode string `json:"errorCode"`
+	Message   string `json:"message"`
+	RequestId string `json:"requestId"`
+}
+
+// s3ErrorBody is the XML error document returned by the S3 API.
+type s3ErrorBody struct {
+	XMLName   xml.Name `xml:"Error"`
+	Code      string   `xml:"Code"`
+	Message   string   `xml:"Message"`
+	RequestId string   `xml:"RequestId"`
+}
+
+// newAPIError builds an APIError from an unsuccessful response and its body.
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		RequestId:  resp.Header.Get("X-Amz-Request-Id"),
+	}
+	if apiErr.RequestId == "" {
+		apiErr.RequestId = resp.Header.Get("X-Request-Id")
+	}
+	if resp.Request != nil {
+		apiErr.Method = resp.Request.Method
+		apiErr.Url = resp.Request.URL.String()
+	}
+
+	var errBody oseErrorBody
+	var s3ErrBody s3ErrorBody
+	if err := json.Unmarshal(body, &errBody); err == nil {
+		apiErr.Code = errBody.Code
+		if apiErr.Code == "" {
+			apiErr.Code = errBody.ErrorCode
+		}
+		apiErr.Message = errBody.Message
+		if errBody.RequestId != "" {
+			apiErr.RequestId = errBody.RequestId
+		}
+	} else if err := xml.Unmarshal(body, &s3ErrBody); err == nil {
+		apiErr.Code = s3ErrBody.Code
+		apiErr.Message = s3ErrBody.Message
+		if s3ErrBody.RequestId != "" {
+			apiErr.RequestId = s3ErrBody.RequestId
+		}
+	} else {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+
+	return apiErr
+}
+
+func hasStatus(err error, statusCode int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == statusCode
+}
+
+// IsNotFound reports whether err is an APIError for a missing bucket or object.
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsConflict reports whether err is an APIError for a conflicting request,
+// e.g. BucketAlreadyExists.
+func IsConflict(err error) bool {
+	return hasStatus(err, http.StatusConflict)
+}
+
+// IsAccessDenied reports whether err is an APIError for a forbidden request.
+func IsAccessDenied(err error) bool {
+	return hasStatus(err, http.StatusForbidden)
+}
@@ -0,0 +1,15 @@
+package pkg
+
+import (
+	"net/http"
+	"time"
+)
+
+// URIEncode exposes the SigV4 URI encoding to the external tests.
+var URIEncode = uriEncode
+
+// SignV4 signs req for service at now, so that signatures can be checked
+// against the known answers of the AWS documentation.
+func SignV4(req *http.Request, accessKey, secretKey, region, service string, now time.Time, payloadHash string) {
+	newSigV4Signer(accessKey, secretKey, "", region).sign(req, now, service, payloadHash)
+}
@@ -0,0 +1,466 @@
+package pkg
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	memoryTenant  = "memory-tenant"
+	memoryOwnerId = "memory-tenant|memory-owner"
+)
+
+// MemoryStorage is an in-memory ObjectStorage. It keeps buckets, objects,
+// tags, ACLs, CORS rules, lifecycle rules, policies, the default encryption
+// and access logging the same way the OSE would, so
+// resources can be exercised offline with resource.UnitTest.
+type MemoryStorage struct {
+	mu      sync.RWMutex
+	region  string
+	buckets map[string]*memoryBucket
+}
+
+type memoryBucket struct {
+	bucket     Bucket
+	region     string
+	tags       []Tag
+	cannedAcl  string
+	grants     []Grant
+	cors       CORSConfiguration
+	lifecycle  []LifecycleRule
+	policy     string
+	encryption []ServerSideEncryptionRule
+	logging    BucketLoggingStatus
+	objects    map[string]MemoryObject
+}
+
+// MemoryObject is an object stored by MemoryStorage.
+type MemoryObject struct {
+	Data         []byte
+	ContentType  string
+	LastModified time.Time
+	// Encryption is the default encryption of the bucket when the object
+	// was stored.
+	Encryption string
+}
+
+func NewMemoryStorage(region string) *MemoryStorage {
+	return &MemoryStorage{
+		region:  region,
+		buckets: map[string]*memoryBucket{},
+	}
+}
+
+func (m *MemoryStorage) bucket(name string) (*memoryBucket, error) {
+	b, ok := m.buckets[name]
+	if !ok {
+		return nil, &APIError{
+			StatusCode: http.StatusNotFound,
+			Code:       "NoSuchBucket",
+			Message:    "The specified bucket does not exist",
+			Url:        name,
+		}
+	}
+	return b, nil
+}
+
+func (m *MemoryStorage) ListBuckets(_ context.Context) ([]string, error) {
+	return m.Buckets(), nil
+}
+
+func (m *MemoryStorage) GetBucket(_ context.Context, name string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	b, err := m.bucket(name)
+	if err != nil {
+		return "", err
+	}
+
+	bucketStr, err := json.Marshal(b.bucket)
+	if err != nil {
+		return "", err
+	}
+
+	return string(bucketStr), nil
+}
+
+func (m *MemoryStorage) CreateBucket(_ context.Context, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.buckets[name]; ok {
+		return &APIError{
+			StatusCode: http.StatusConflict,
+			Code:       "BucketAlreadyExists",
+			Message:    "The requested bucket name is not available",
+			Method:     http.MethodPut,
+			Url:        name,
+		}
+	}
+
+	owner := Owner{Id: memoryOwnerId, DisplayName: "memory-owner"}
+	m.buckets[name] = &memoryBucket{
+		bucket: Bucket{
+			Name:   name,
+			Tenant: memoryTenant,
+			Owner:  owner,
+		},
+		region:  m.region,
+		grants:  []Grant{{Grantee: userGrantee(owner.Id), Permission: "FULL_CONTROL"}},
+		objects: map[string]MemoryObject{},
+	}
+
+	return nil
+}
+
+func (m *MemoryStorage) BucketTags(_ context.Context, bucket string, tags []Tag) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, err := m.bucket(bucket)
+	if err != nil {
+		return err
+	}
+
+	b.tags = append([]Tag(nil), tags...)
+
+	return nil
+}
+
+func (m *MemoryStorage) GetBucketTags(_ context.Context, bucket string) ([]Tag, error) {
+	return m.Tags(bucket)
+}
+
+func (m *MemoryStorage) BucketAcls(_ context.Context, bucket, cannedAcl string, acls []BucketAcl) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, err := m.bucket(bucket)
+	if err != nil {
+		return err
+	}
+
+	b.cannedAcl = cannedAcl
+	b.grants = aclGrants(&b.bucket, acls)
+
+	return nil
+}
+
+func (m *MemoryStorage) GetBucketAcl(_ context.Context, bucket string) (*AccessControlPolicy, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	b, err := m.bucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AccessControlPolicy{Owner: b.bucket.Owner, Grants: append([]Grant(nil), b.grants...)}, nil
+}
+
+func (m *MemoryStorage) BucketCors(_ context.Context, bucket string, rules []CORSRule) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, err := m.bucket(bucket)
+	if err != nil {
+		return err
+	}
+
+	b.cors = CORSConfiguration{Rules: append([]CORSRule(nil), rules...)}
+
+	return nil
+}
+
+func (m *MemoryStorage) GetBucketCors(_ context.Context, bucket string) ([]CORSRule, error) {
+	return m.Cors(bucket)
+}
+
+func (m *MemoryStorage) BucketLifecycle(_ context.Context, bucket string, lifecycle LifecycleConfiguration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, err := m.bucket(bucket)
+	if err != nil {
+		return err
+	}
+
+	b.lifecycle = append([]LifecycleRule(nil), lifecycle.Rules...)
+
+	return nil
+}
+
+func (m *MemoryStorage) GetBucketLifecycle(_ context.Context, bucket string) ([]LifecycleRule, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	b, err := m.bucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]LifecycleRule(nil), b.lifecycle...), nil
+}
+
+func (m *MemoryStorage) BucketPolicy(_ context.Context, bucket, policy string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, err := m.bucket(bucket)
+	if err != nil {
+		return err
+	}
+
+	b.policy = policy
+
+	return nil
+}
+
+func (m *MemoryStorage) GetBucketPolicy(_ context.Context, bucket string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	b, err := m.bucket(bucket)
+	if err != nil {
+		return "", err
+	}
+
+	return b.policy, nil
+}
+
+func (m *MemoryStorage) BucketEncryption(_ context.Context, bucket string, encryption ServerSideEncryptionConfiguration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, err := m.bucket(bucket)
+	if err != nil {
+		return err
+	}
+
+	b.encryption = append([]ServerSideEncryptionRule(nil), encryption.Rules...)
+
+	return nil
+}
+
+func (m *MemoryStorage) GetBucketEncryption(_ context.Context, bucket string) ([]ServerSideEncryptionRule, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	b, err := m.bucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]ServerSideEncryptionRule(nil), b.encryption...), nil
+}
+
+func (m *MemoryStorage) BucketLogging(_ context.Context, bucket string, logging BucketLoggingStatus) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, err := m.bucket(bucket)
+	if err != nil {
+		return err
+	}
+
+	b.logging = logging
+
+	return nil
+}
+
+func (m *MemoryStorage) GetBucketLogging(_ context.Context, bucket string) (*BucketLoggingStatus, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	b, err := m.bucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	logging := b.logging
+	return &logging, nil
+}
+
+func (m *MemoryStorage) EnsureLogDeliveryGrants(_ context.Context, bucket string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, err := m.bucket(bucket)
+	if err != nil {
+		return err
+	}
+
+	b.grants, _ = withLogDeliveryGrants(b.grants)
+
+	return nil
+}
+
+func (m *MemoryStorage) UploadObject(_ context.Context, bucket, key, source string, overwrite bool) error {
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, err := m.bucket(bucket)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := b.objects[key]; ok && !overwrite {
+		return &APIError{
+			StatusCode: http.StatusConflict,
+			Code:       "ObjectAlreadyExists",
+			Message:    "The object already exists and overwrite is disabled",
+			Method:     http.MethodPut,
+			Url:        bucket + "/" + key,
+		}
+	}
+
+	object := MemoryObject{Data: data, ContentType: http.DetectContentType(data), LastModified: time.Now().UTC()}
+	if len(b.encryption) > 0 {
+		object.Encryption = b.encryption[0].ApplyServerSideEncryptionByDefault.SSEAlgorithm
+	}
+	b.objects[key] = object
+
+	return nil
+}
+
+func (m *MemoryStorage) HeadObject(_ context.Context, bucket, key string) (*ObjectInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	b, err := m.bucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	o, ok := b.objects[key]
+	if !ok {
+		return nil, &APIError{
+			StatusCode: http.StatusNotFound,
+			Code:       "NoSuchKey",
+			Message:    "The specified key does not exist",
+			Method:     http.MethodHead,
+			Url:        bucket + "/" + key,
+		}
+	}
+
+	sum := md5.Sum(o.Data)
+	if o.Encryption == SSEAlgorithmKMS {
+		sum = md5.Sum(append(sum[:], o.Encryption...))
+	}
+	return &ObjectInfo{
+		ETag:                 hex.EncodeToString(sum[:]),
+		Size:                 int64(len(o.Data)),
+		ContentType:          o.ContentType,
+		LastModified:         o.LastModified,
+		ServerSideEncryption: o.Encryption,
+	}, nil
+}
+
+// DeleteObject removes the object. MemoryStorage keeps a single version of
+// every object, so allVersions makes no difference.
+func (m *MemoryStorage) DeleteObject(_ context.Context, bucket, key string, _ bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, err := m.bucket(bucket)
+	if err != nil {
+		return err
+	}
+
+	delete(b.objects, key)
+
+	return nil
+}
+
+func (m *MemoryStorage) DeleteBucket(_ context.Context, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, err := m.bucket(name); err != nil {
+		return err
+	}
+
+	delete(m.buckets, name)
+
+	return nil
+}
+
+// Buckets returns the names of all buckets, sorted.
+func (m *MemoryStorage) Buckets() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make([]string, 0, len(m.buckets))
+	for name := range m.buckets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Tags returns the tags currently set on the bucket.
+func (m *MemoryStorage) Tags(bucket string) ([]Tag, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	b, err := m.bucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]Tag(nil), b.tags...), nil
+}
+
+// Acl returns the canned ACL and the grants currently set on the bucket.
+func (m *MemoryStorage) Acl(bucket string) (string, []Grant, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	b, err := m.bucket(bucket)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return b.cannedAcl, append([]Grant(nil), b.grants...), nil
+}
+
+// Cors returns the CORS rules currently set on the bucket.
+func (m *MemoryStorage) Cors(bucket string) ([]CORSRule, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	b, err := m.bucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]CORSRule(nil), b.cors.Rules...), nil
+}
+
+// Object returns the object stored under key in bucket.
+func (m *MemoryStorage) Object(bucket, key string) (MemoryObject, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	b, err := m.bucket(bucket)
+	if err != nil {
+		return MemoryObject{}, false
+	}
+
+	o, ok := b.objects[key]
+	return o, ok
+}
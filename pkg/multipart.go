@@ -0,0 +1,183 @@
+package pkg
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// minPartSize is the smallest part size accepted by S3 for all but the last part.
+	minPartSize = 5 << 20
+	// maxParts is the maximum number of parts of a multipart upload.
+	maxParts = 10000
+)
+
+// MultipartConfig controls when and how S3Client uploads objects in parts.
+type MultipartConfig struct {
+	// Threshold is the object size, in bytes, from which multipart upload is used.
+	Threshold int64
+	// PartSize is the size, in bytes, of every part but the last one.
+	PartSize int64
+	// Concurrency is the number of parts uploaded in parallel.
+	Concurrency int
+}
+
+func DefaultMultipartConfig() MultipartConfig {
+	return MultipartConfig{
+		Threshold:   100 << 20,
+		PartSize:    16 << 20,
+		Concurrency: 4,
+	}
+}
+
+// partSize returns the part size to use for an object of size bytes, grown
+// when needed so the upload fits in maxParts.
+func (c MultipartConfig) partSize(size int64) int64 {
+	partSize := max(c.PartSize, minPartSize)
+	if minSize := (size + maxParts - 1) / maxParts; partSize < minSize {
+		partSize = minSize
+	}
+	return partSize
+}
+
+// WithMultipartConfig sets when and how large objects are uploaded in parts.
+func WithMultipartConfig(config MultipartConfig) S3ClientOption {
+	return func(s *S3Client) {
+		s.multipart = config
+	}
+}
+
+type multipartUpload struct {
+	Bucket   string `json:"bucket" xml:"Bucket"`
+	Key      string `json:"key" xml:"Key"`
+	UploadId string `json:"uploadId" xml:"UploadId"`
+}
+
+type completeMultipartUpload struct {
+	XMLName xml.Name        `json:"-" xml:"CompleteMultipartUpload"`
+	Parts   []completedPart `json:"parts" xml:"Part"`
+}
+
+type completedPart struct {
+	PartNumber int    `json:"partNumber" xml:"PartNumber"`
+	ETag       string `json:"etag" xml:"ETag"`
+}
+
+// uploadParts uploads file in parts. Every part is read from its own section
+// of the file, so a failed part is retried on its own without restarting the
+// upload. The multipart upload is aborted when any part fails.
+func (s S3Client) uploadParts(ctx context.Context, bucket, key string, overwrite bool, file *os.File, size int64, contentType string) error {
+	objectPath := objectResource(bucket, key)
+
+	query := "uploads"
+	if _, ok := s.protocol.(oseProtocol); ok {
+		query = fmt.Sprintf("uploads&overwrite=%t", overwrite)
+	}
+
+	resp, err := s.doRequest(ctx, http.MethodPost, s.mountUrl(objectPath, query), "", map[string]string{"Content-Type": contentType})
+	if err != nil {
+		return fmt.Errorf("initiating multipart upload of %s: %w", objectPath, err)
+	}
+
+	var upload multipartUpload
+	if err := s.protocol.unmarshal([]byte(resp), &upload); err != nil {
+		return fmt.Errorf("reading multipart upload of %s: %w", objectPath, err)
+	}
+	if upload.UploadId == "" {
+		return fmt.Errorf("initiating multipart upload of %s: no upload id returned", objectPath)
+	}
+
+	partSize := s.multipart.partSize(size)
+	partCount := int((size + partSize - 1) / partSize)
+	log.Printf("[INFO] Uploading %s in %d parts of %d bytes (upload id %s)", objectPath, partCount, partSize, upload.UploadId)
+
+	parts := make([]completedPart, partCount)
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(max(s.multipart.Concurrency, 1))
+	for i := 0; i < partCount; i++ {
+		partNumber := i + 1
+		offset := int64(i) * partSize
+		length := min(partSize, size-offset)
+		g.Go(func() error {
+			etag, err := s.uploadPart(gctx, objectPath, upload.UploadId, partNumber, io.NewSectionReader(file, offset, length), length)
+			if err != nil {
+				return fmt.Errorf("uploading part %d of %s: %w", partNumber, objectPath, err)
+			}
+			parts[partNumber-1] = completedPart{PartNumber: partNumber, ETag: etag}
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		s.abortUpload(objectPath, upload.UploadId)
+		return err
+	}
+
+	if err := s.completeUpload(ctx, objectPath, upload.UploadId, parts); err != nil {
+		s.abortUpload(objectPath, upload.UploadId)
+		return err
+	}
+
+	return nil
+}
+
+func (s S3Client) uploadPart(ctx context.Context, objectPath, uploadId string, partNumber int, part *io.SectionReader, length int64) (string, error) {
+	partUrl := s.mountUrl(objectPath, fmt.Sprintf("partNumber=%d&uploadId=%s", partNumber, url.QueryEscape(uploadId)))
+
+	resp, err := s.send(ctx, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPut, partUrl, io.NewSectionReader(part, 0, length))
+		if err != nil {
+			return nil, err
+		}
+
+		req.ContentLength = length
+		req.Header.Add("Content-Type", "application/octet-stream")
+		return req, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		return "", fmt.Errorf("no ETag returned for part %d", partNumber)
+	}
+
+	log.Printf("[DEBUG] Uploaded part %d of %s", partNumber, objectPath)
+	return strings.Trim(etag, `"`), nil
+}
+
+func (s S3Client) completeUpload(ctx context.Context, objectPath, uploadId string, parts []completedPart) error {
+	sort.Slice(parts, func(i, j int) bool { return parts[i].PartNumber < parts[j].PartNumber })
+
+	payload, err := s.protocol.marshal(completeMultipartUpload{Parts: parts})
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.doRequest(ctx, http.MethodPost, s.mountUrl(objectPath, "uploadId="+url.QueryEscape(uploadId)), string(payload), nil); err != nil {
+		return fmt.Errorf("completing multipart upload of %s: %w", objectPath, err)
+	}
+
+	return nil
+}
+
+// abortUpload discards the uploaded parts. It runs on a fresh context so the
+// parts are released even when the upload was cancelled.
+func (s S3Client) abortUpload(objectPath, uploadId string) {
+	if _, err := s.doRequest(context.Background(), http.MethodDelete, s.mountUrl(objectPath, "uploadId="+url.QueryEscape(uploadId)), "", nil); err != nil {
+		log.Printf("[WARN] Error aborting multipart upload %s of %s: %s", uploadId, objectPath, err)
+	}
+}
@@ -0,0 +1,994 @@
+// Package osetest emulates the Object Storage Extension api/v1/s3 endpoints
+// used by pkg.S3Client, so the client and the provider can be tested over
+// real HTTP without a VCD. Only the behaviour the provider relies on is
+// emulated: requests must carry an Authorization header, bearer tokens must
+// have been issued by the emulated VCD token endpoint, and signatures are not
+// checked.
+package osetest
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/josajunior81/terraform-provider-vcd-object-storage-ext/pkg"
+	"github.com/vmware/go-vcloud-director/v2/types/v56"
+)
+
+const (
+	// Tenant is the tenant owning every bucket of the emulator.
+	Tenant = "osetest-tenant"
+	// OwnerId is the canonical id of the bucket owner.
+	OwnerId = Tenant + "|osetest-owner"
+	// APIToken is the VCD API token exchanged for bearer tokens. Pass it to
+	// pkg.NewS3Client with the server URL as the VCD url.
+	APIToken = "osetest-api-token"
+
+	allUsers           = "http://acs.amazonaws.com/groups/global/AllUsers"
+	authenticatedUsers = "http://acs.amazonaws.com/groups/global/AuthenticatedUsers"
+	logDelivery        = "http://acs.amazonaws.com/groups/s3/LogDelivery"
+)
+
+// Server is a running emulator. Point pkg.NewS3ClientWithKeys, or the
+// provider s3_url, at its URL.
+type Server struct {
+	*httptest.Server
+
+	// MaxKeys is the page size of version listings. Default 1000.
+	MaxKeys int
+	// TokenExpiresIn is the lifetime, in seconds, announced with every
+	// bearer token. Default 3600.
+	TokenExpiresIn int
+	// Intercept is called with every request before the emulator handles
+	// it. When it returns true the request is considered answered, which
+	// lets tests inject failures.
+	Intercept func(w http.ResponseWriter, r *http.Request) bool
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	uploads   map[string]*upload
+	sequence  int
+	tokens    map[string]bool
+	exchanges int
+}
+
+type bucket struct {
+	tags       []pkg.Tag
+	grants     []pkg.Grant
+	cors       *pkg.CORSConfiguration
+	versioned  bool
+	lifecycle  *pkg.LifecycleConfiguration
+	policy     map[string]interface{}
+	encryption *pkg.ServerSideEncryptionConfiguration
+	logging    pkg.BucketLoggingStatus
+	// objects holds the versions of every key, oldest first.
+	objects map[string][]*version
+	// purging is set by an asynchronous purge. The bucket is emptied once
+	// the purge has been observed by one version listing.
+	purging bool
+}
+
+type version struct {
+	id           string
+	data         []byte
+	contentType  string
+	etag         string
+	encryption   string
+	lastModified time.Time
+	deleteMarker bool
+}
+
+type upload struct {
+	bucket      string
+	key         string
+	contentType string
+	parts       map[int][]byte
+}
+
+// NewServer starts an emulator. Close it when done.
+func NewServer() *Server {
+	s := &Server{
+		MaxKeys: 1000,
+		buckets: map[string]*bucket{},
+		uploads: map[string]*upload{},
+		tokens:  map[string]bool{},
+
+		TokenExpiresIn: 3600,
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Buckets returns the names of every bucket, sorted.
+func (s *Server) Buckets() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.sortedBuckets()
+}
+
+// Object returns the current content of bucket/key.
+func (s *Server) Object(bucketName, key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[bucketName]
+	if !ok {
+		return nil, false
+	}
+	v := b.current(key)
+	if v == nil {
+		return nil, false
+	}
+	return v.data, true
+}
+
+// Uploads returns the number of multipart uploads neither completed nor
+// aborted.
+func (s *Server) Uploads() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.uploads)
+}
+
+// TokenExchanges returns how many times APIToken was exchanged for a bearer
+// token.
+func (s *Server) TokenExchanges() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.exchanges
+}
+
+// ExpireTokens revokes every bearer token issued so far, as VCD does when
+// they lapse or the session is closed. Requests carrying them get a 401.
+func (s *Server) ExpireTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	clear(s.tokens)
+}
+
+// Versions returns the number of versions and delete markers of bucket/key.
+func (s *Server) Versions(bucketName, key string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if b, ok := s.buckets[bucketName]; ok {
+		return len(b.objects[key])
+	}
+	return 0
+}
+
+// EnableVersioning turns versioning on for bucket, so objects keep their
+// previous versions and deletes add delete markers.
+func (s *Server) EnableVersioning(bucketName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if b, ok := s.buckets[bucketName]; ok {
+		b.versioned = true
+	}
+}
+
+func (b *bucket) current(key string) *version {
+	versions := b.objects[key]
+	if len(versions) == 0 || versions[len(versions)-1].deleteMarker {
+		return nil
+	}
+	return versions[len(versions)-1]
+}
+
+func (s *Server) nextId() string {
+	s.sequence++
+	return fmt.Sprintf("%08d", s.sequence)
+}
+
+type errorBody struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestId string `json:"requestId"`
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(errorBody{Code: code, Message: message, RequestId: "osetest"})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "MalformedJSON", err.Error())
+		return false
+	}
+	return true
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.Intercept != nil && s.Intercept(w, r) {
+		return
+	}
+
+	if strings.HasPrefix(r.URL.Path, "/oauth/") {
+		s.exchangeToken(w, r)
+		return
+	}
+
+	authorization := r.Header.Get("Authorization")
+	if authorization == "" {
+		writeError(w, http.StatusForbidden, "AccessDenied", "Missing Authorization header")
+		return
+	}
+
+	resource, ok := strings.CutPrefix(r.URL.Path, "/"+pkg.PATH+"/")
+	if !ok {
+		writeError(w, http.StatusNotFound, "NotFound", "Unknown path "+r.URL.Path)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if token, ok := strings.CutPrefix(authorization, "Bearer "); ok && !s.tokens[token] {
+		writeError(w, http.StatusUnauthorized, "Unauthorized", "Invalid or expired bearer token")
+		return
+	}
+
+	bucketName, key, _ := strings.Cut(resource, "/")
+	switch {
+	case bucketName == "":
+		s.listBuckets(w, r)
+	case key == "":
+		s.serveBucket(w, r, bucketName)
+	default:
+		s.serveObject(w, r, bucketName, key)
+	}
+}
+
+// exchangeToken emulates the VCD /oauth/tenant/{org}/token endpoint, which
+// exchanges an API token for a bearer token.
+func (s *Server) exchangeToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, "BadRequest", err.Error())
+		return
+	}
+	if r.PostForm.Get("grant_type") != "refresh_token" || r.PostForm.Get("refresh_token") != APIToken {
+		writeError(w, http.StatusBadRequest, "invalid_grant", "Invalid API token")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.exchanges++
+	token := fmt.Sprintf("osetest-bearer-%d", s.exchanges)
+	s.tokens[token] = true
+
+	writeJSON(w, types.ApiTokenRefresh{
+		AccessToken:  token,
+		TokenType:    "Bearer",
+		ExpiresIn:    s.TokenExpiresIn,
+		RefreshToken: APIToken,
+	})
+}
+
+func (s *Server) listBuckets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method)
+		return
+	}
+
+	var list struct {
+		Buckets []pkg.Bucket `json:"buckets"`
+	}
+	for _, name := range s.sortedBuckets() {
+		list.Buckets = append(list.Buckets, s.bucketDocument(name))
+	}
+	writeJSON(w, list)
+}
+
+func (s *Server) sortedBuckets() []string {
+	names := make([]string, 0, len(s.buckets))
+	for name := range s.buckets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *Server) bucketDocument(name string) pkg.Bucket {
+	return pkg.Bucket{
+		Name:   name,
+		Tenant: Tenant,
+		S3Href: s.URL + "/" + pkg.PATH + "/" + name,
+		Owner:  pkg.Owner{Id: OwnerId, DisplayName: "osetest-owner"},
+	}
+}
+
+func (s *Server) serveBucket(w http.ResponseWriter, r *http.Request, name string) {
+	query := r.URL.Query()
+
+	if r.Method == http.MethodPut && len(query) == 0 {
+		s.createBucket(w, name)
+		return
+	}
+
+	b, ok := s.buckets[name]
+	if !ok {
+		writeError(w, http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist")
+		return
+	}
+
+	switch {
+	case query.Has("versions") && r.Method == http.MethodGet:
+		s.listVersions(w, query, b)
+	case query.Has("tagging"):
+		s.serveTagging(w, r, b)
+	case query.Has("acl"):
+		s.serveAcl(w, r, b)
+	case query.Has("cors"):
+		s.serveCors(w, r, b)
+	case query.Has("lifecycle"):
+		s.serveLifecycle(w, r, b)
+	case query.Has("policy"):
+		s.servePolicy(w, r, b)
+	case query.Has("encryption"):
+		s.serveEncryption(w, r, b)
+	case query.Has("logging"):
+		s.serveLogging(w, r, b)
+	case query.Has("delete") && r.Method == http.MethodPost:
+		s.purgeBucket(w, r, b)
+	case r.Method == http.MethodGet:
+		writeJSON(w, s.bucketDocument(name))
+	case r.Method == http.MethodHead:
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodDelete:
+		s.deleteBucket(w, name, b)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method+" "+r.URL.String())
+	}
+}
+
+func (s *Server) createBucket(w http.ResponseWriter, name string) {
+	if _, ok := s.buckets[name]; ok {
+		writeError(w, http.StatusConflict, "BucketAlreadyExists", "The requested bucket name is not available")
+		return
+	}
+
+	s.buckets[name] = &bucket{
+		grants:  cannedGrants("private"),
+		objects: map[string][]*version{},
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) deleteBucket(w http.ResponseWriter, name string, b *bucket) {
+	if len(b.objects) > 0 {
+		writeError(w, http.StatusConflict, "BucketNotEmpty", "The bucket you tried to delete is not empty")
+		return
+	}
+
+	delete(s.buckets, name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) purgeBucket(w http.ResponseWriter, r *http.Request, b *bucket) {
+	var purge struct {
+		RemoveAll bool `json:"removeAll"`
+		TryAsync  bool `json:"tryAsync"`
+	}
+	if !readJSON(w, r, &purge) {
+		return
+	}
+	if !purge.RemoveAll {
+		writeError(w, http.StatusNotImplemented, "NotImplemented", "Only removeAll purges are emulated")
+		return
+	}
+
+	if purge.TryAsync {
+		b.purging = true
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	b.objects = map[string][]*version{}
+	w.WriteHeader(http.StatusOK)
+}
+
+type objectIdentity struct {
+	Key       string `json:"key"`
+	VersionId string `json:"versionId,omitempty"`
+}
+
+func (s *Server) listVersions(w http.ResponseWriter, query url.Values, b *bucket) {
+	var entries []struct {
+		objectIdentity
+		deleteMarker bool
+	}
+	keys := make([]string, 0, len(b.objects))
+	for key := range b.objects {
+		if strings.HasPrefix(key, query.Get("prefix")) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, v := range b.objects[key] {
+			entries = append(entries, struct {
+				objectIdentity
+				deleteMarker bool
+			}{objectIdentity{Key: key, VersionId: v.id}, v.deleteMarker})
+		}
+	}
+
+	if marker := query.Get("key-marker"); marker != "" {
+		for i, e := range entries {
+			if e.Key == marker && e.VersionId == query.Get("version-id-marker") {
+				entries = entries[i+1:]
+				break
+			}
+		}
+	}
+
+	maxKeys := s.MaxKeys
+	if n, err := strconv.Atoi(query.Get("max-keys")); err == nil && n > 0 && n < maxKeys {
+		maxKeys = n
+	}
+
+	var list struct {
+		IsTruncated         bool             `json:"isTruncated"`
+		NextKeyMarker       string           `json:"nextKeyMarker,omitempty"`
+		NextVersionIdMarker string           `json:"nextVersionIdMarker,omitempty"`
+		Versions            []objectIdentity `json:"versions"`
+		DeleteMarkers       []objectIdentity `json:"deleteMarkers"`
+	}
+	if len(entries) > maxKeys {
+		entries = entries[:maxKeys]
+		list.IsTruncated = true
+		list.NextKeyMarker = entries[maxKeys-1].Key
+		list.NextVersionIdMarker = entries[maxKeys-1].VersionId
+	}
+	for _, e := range entries {
+		if e.deleteMarker {
+			list.DeleteMarkers = append(list.DeleteMarkers, e.objectIdentity)
+		} else {
+			list.Versions = append(list.Versions, e.objectIdentity)
+		}
+	}
+
+	// The asynchronous purge completes once it has been observed, so
+	// clients have to poll for it.
+	if b.purging {
+		b.objects = map[string][]*version{}
+		b.purging = false
+	}
+
+	writeJSON(w, list)
+}
+
+func (s *Server) serveTagging(w http.ResponseWriter, r *http.Request, b *bucket) {
+	switch r.Method {
+	case http.MethodGet:
+		if len(b.tags) == 0 {
+			writeError(w, http.StatusNotFound, "NoSuchTagSet", "The TagSet does not exist")
+			return
+		}
+		writeJSON(w, pkg.Tagging{TagSets: []pkg.TagSet{{Tags: b.tags}}})
+	case http.MethodPut:
+		var tagging pkg.Tagging
+		if !readJSON(w, r, &tagging) {
+			return
+		}
+		b.tags = nil
+		for _, tagSet := range tagging.TagSets {
+			b.tags = append(b.tags, tagSet.Tags...)
+		}
+		w.WriteHeader(http.StatusOK)
+	case http.MethodDelete:
+		b.tags = nil
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method)
+	}
+}
+
+func (s *Server) serveAcl(w http.ResponseWriter, r *http.Request, b *bucket) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, pkg.AccessControlPolicy{
+			Owner:  pkg.Owner{Id: OwnerId, DisplayName: "osetest-owner"},
+			Grants: b.grants,
+		})
+	case http.MethodPut:
+		if canned := r.Header.Get("X-Amz-Acl"); canned != "" {
+			grants := cannedGrants(canned)
+			if grants == nil {
+				writeError(w, http.StatusBadRequest, "InvalidArgument", "Unknown canned ACL "+canned)
+				return
+			}
+			b.grants = grants
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		var acl pkg.AccessControlPolicy
+		if !readJSON(w, r, &acl) {
+			return
+		}
+		b.grants = acl.Grants
+		w.WriteHeader(http.StatusOK)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method)
+	}
+}
+
+// cannedGrants expands a canned ACL into its grants, or returns nil for an
+// unknown canned ACL.
+func cannedGrants(canned string) []pkg.Grant {
+	grants := []pkg.Grant{{Grantee: pkg.Grantee{Id: OwnerId}, Permission: "FULL_CONTROL"}}
+	group := func(uri, permission string) {
+		grants = append(grants, pkg.Grant{Grantee: pkg.Grantee{Uri: uri}, Permission: permission})
+	}
+	tenant := func(permission string) {
+		grants = append(grants, pkg.Grant{Grantee: pkg.Grantee{Id: Tenant + "|"}, Permission: permission})
+	}
+
+	switch canned {
+	case "private":
+	case "public-read":
+		group(allUsers, "READ")
+	case "public-read-write":
+		group(allUsers, "READ")
+		group(allUsers, "WRITE")
+	case "authenticated-read":
+		group(authenticatedUsers, "READ")
+	case "group-read":
+		tenant("READ")
+	case "group-read-write":
+		tenant("READ")
+		tenant("WRITE")
+	case "log-delivery-write":
+		group(logDelivery, "WRITE")
+		group(logDelivery, "READ_ACP")
+	default:
+		return nil
+	}
+
+	return grants
+}
+
+func (s *Server) serveCors(w http.ResponseWriter, r *http.Request, b *bucket) {
+	switch r.Method {
+	case http.MethodGet:
+		if b.cors == nil {
+			writeError(w, http.StatusNotFound, "NoSuchCORSConfiguration", "The CORS configuration does not exist")
+			return
+		}
+		writeJSON(w, b.cors)
+	case http.MethodPut:
+		var cors pkg.CORSConfiguration
+		if !readJSON(w, r, &cors) {
+			return
+		}
+		b.cors = &cors
+		w.WriteHeader(http.StatusOK)
+	case http.MethodDelete:
+		if b.cors == nil {
+			writeError(w, http.StatusNotFound, "NoSuchCORSConfiguration", "The CORS configuration does not exist")
+			return
+		}
+		b.cors = nil
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method)
+	}
+}
+
+func (s *Server) serveLifecycle(w http.ResponseWriter, r *http.Request, b *bucket) {
+	switch r.Method {
+	case http.MethodGet:
+		if b.lifecycle == nil {
+			writeError(w, http.StatusNotFound, "NoSuchLifecycleConfiguration", "The lifecycle configuration does not exist")
+			return
+		}
+		writeJSON(w, b.lifecycle)
+	case http.MethodPut:
+		var lifecycle pkg.LifecycleConfiguration
+		if !readJSON(w, r, &lifecycle) {
+			return
+		}
+		if err := validateLifecycle(lifecycle); err != "" {
+			writeError(w, http.StatusBadRequest, "InvalidArgument", err)
+			return
+		}
+		b.lifecycle = &lifecycle
+		w.WriteHeader(http.StatusOK)
+	case http.MethodDelete:
+		b.lifecycle = nil
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method)
+	}
+}
+
+// validateLifecycle returns why the lifecycle configuration would be refused,
+// or an empty string.
+func validateLifecycle(lifecycle pkg.LifecycleConfiguration) string {
+	if len(lifecycle.Rules) == 0 {
+		return "At least one lifecycle rule is required"
+	}
+
+	ids := map[string]bool{}
+	for _, rule := range lifecycle.Rules {
+		switch {
+		case rule.Id == "" || ids[rule.Id]:
+			return "Rule ID must be unique and not empty: " + rule.Id
+		case rule.Status != pkg.LifecycleEnabled && rule.Status != pkg.LifecycleDisabled:
+			return "Invalid status " + rule.Status
+		case rule.Expiration == nil && rule.NoncurrentVersionExpiration == nil && rule.AbortIncompleteMultipartUpload == nil:
+			return "At least one action needs to be specified in rule " + rule.Id
+		case rule.Expiration != nil && (rule.Expiration.Days == 0) == (rule.Expiration.Date == ""):
+			return "Expiration of rule " + rule.Id + " needs exactly one of Days and Date"
+		}
+		if rule.Expiration != nil && rule.Expiration.Date != "" {
+			date, err := time.Parse(time.RFC3339, rule.Expiration.Date)
+			if err != nil || !date.Equal(date.Truncate(24*time.Hour)) {
+				return "Date must be at midnight GMT: " + rule.Expiration.Date
+			}
+		}
+		ids[rule.Id] = true
+	}
+
+	return ""
+}
+
+// servePolicy stores the policy decoded, so it is returned with its keys
+// sorted and without whitespace like the OSE reformats it.
+func (s *Server) servePolicy(w http.ResponseWriter, r *http.Request, b *bucket) {
+	switch r.Method {
+	case http.MethodGet:
+		if b.policy == nil {
+			writeError(w, http.StatusNotFound, "NoSuchBucketPolicy", "The bucket policy does not exist")
+			return
+		}
+		writeJSON(w, b.policy)
+	case http.MethodPut:
+		var policy map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+			writeError(w, http.StatusBadRequest, "MalformedPolicy", "Policies must be valid JSON: "+err.Error())
+			return
+		}
+		if !hasStatement(policy) {
+			writeError(w, http.StatusBadRequest, "MalformedPolicy", "Missing required field Statement")
+			return
+		}
+		b.policy = policy
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodDelete:
+		b.policy = nil
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method)
+	}
+}
+
+// hasStatement reports whether policy has a statement, given as a single
+// object or as a list.
+func hasStatement(policy map[string]interface{}) bool {
+	switch statement := policy["Statement"].(type) {
+	case map[string]interface{}:
+		return true
+	case []interface{}:
+		return len(statement) > 0
+	default:
+		return false
+	}
+}
+
+func (s *Server) serveEncryption(w http.ResponseWriter, r *http.Request, b *bucket) {
+	switch r.Method {
+	case http.MethodGet:
+		if b.encryption == nil {
+			writeError(w, http.StatusNotFound, "ServerSideEncryptionConfigurationNotFoundError", "The server side encryption configuration was not found")
+			return
+		}
+		writeJSON(w, b.encryption)
+	case http.MethodPut:
+		var encryption pkg.ServerSideEncryptionConfiguration
+		if !readJSON(w, r, &encryption) {
+			return
+		}
+		if len(encryption.Rules) != 1 {
+			writeError(w, http.StatusBadRequest, "MalformedXML", "Exactly one encryption rule is required")
+			return
+		}
+		switch byDefault := encryption.Rules[0].ApplyServerSideEncryptionByDefault; {
+		case byDefault.SSEAlgorithm != pkg.SSEAlgorithmAES256 && byDefault.SSEAlgorithm != pkg.SSEAlgorithmKMS:
+			writeError(w, http.StatusBadRequest, "InvalidArgument", "Invalid SSEAlgorithm "+byDefault.SSEAlgorithm)
+			return
+		case byDefault.KMSMasterKeyID != "" && byDefault.SSEAlgorithm != pkg.SSEAlgorithmKMS:
+			writeError(w, http.StatusBadRequest, "InvalidArgument", "KMSMasterKeyID requires the aws:kms SSEAlgorithm")
+			return
+		}
+		b.encryption = &encryption
+		w.WriteHeader(http.StatusOK)
+	case http.MethodDelete:
+		b.encryption = nil
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method)
+	}
+}
+
+func (s *Server) serveLogging(w http.ResponseWriter, r *http.Request, b *bucket) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, b.logging)
+	case http.MethodPut:
+		var logging pkg.BucketLoggingStatus
+		if !readJSON(w, r, &logging) {
+			return
+		}
+		if enabled := logging.LoggingEnabled; enabled != nil {
+			target, ok := s.buckets[enabled.TargetBucket]
+			if !ok {
+				writeError(w, http.StatusBadRequest, "InvalidTargetBucketForLogging", "The target bucket for logging does not exist")
+				return
+			}
+			if !hasGrant(target, logDelivery, "WRITE") || !hasGrant(target, logDelivery, "READ_ACP") {
+				writeError(w, http.StatusBadRequest, "InvalidTargetBucketForLogging", "You must give the log-delivery group WRITE and READ_ACP permissions to the target bucket")
+				return
+			}
+		}
+		b.logging = logging
+		w.WriteHeader(http.StatusOK)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method)
+	}
+}
+
+// hasGrant reports whether the group uri holds permission on b.
+func hasGrant(b *bucket, uri, permission string) bool {
+	for _, grant := range b.grants {
+		if grant.Grantee.Uri == uri && grant.Permission == permission {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) serveObject(w http.ResponseWriter, r *http.Request, bucketName, key string) {
+	b, ok := s.buckets[bucketName]
+	if !ok {
+		writeError(w, http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist")
+		return
+	}
+
+	query := r.URL.Query()
+	switch {
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		s.initiateUpload(w, r, bucketName, key)
+	case r.Method == http.MethodPut && query.Has("uploadId"):
+		s.uploadPart(w, r, query)
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		s.completeUpload(w, r, b, key, query.Get("uploadId"))
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		s.abortUpload(w, query.Get("uploadId"))
+	case r.Method == http.MethodPut:
+		s.putObject(w, r, b, key)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		s.getObject(w, r, b, key)
+	case r.Method == http.MethodDelete:
+		s.deleteObject(w, query, b, key)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method+" "+r.URL.String())
+	}
+}
+
+func (s *Server) putObject(w http.ResponseWriter, r *http.Request, b *bucket, key string) {
+	if r.URL.Query().Get("overwrite") == "false" && b.current(key) != nil {
+		writeError(w, http.StatusConflict, "ObjectAlreadyExists", "The object already exists and overwrite is disabled")
+		return
+	}
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "IncompleteBody", err.Error())
+		return
+	}
+
+	sum := md5.Sum(data)
+	v := s.store(b, key, &version{
+		data:        data,
+		contentType: r.Header.Get("Content-Type"),
+		etag:        hex.EncodeToString(sum[:]),
+	})
+
+	w.Header().Set("ETag", `"`+v.etag+`"`)
+	w.WriteHeader(http.StatusOK)
+}
+
+// store adds v as the current version of key. Without versioning enabled the
+// previous null version is replaced.
+func (s *Server) store(b *bucket, key string, v *version) *version {
+	v.lastModified = time.Now().UTC().Truncate(time.Second)
+	if b.encryption != nil && len(b.encryption.Rules) > 0 && !v.deleteMarker {
+		v.encryption = b.encryption.Rules[0].ApplyServerSideEncryptionByDefault.SSEAlgorithm
+		// Objects encrypted with SSE-KMS have an etag that is not the
+		// MD5 of their content, as on S3.
+		if v.encryption == pkg.SSEAlgorithmKMS && !strings.Contains(v.etag, "-") {
+			sum := md5.Sum([]byte(v.etag + v.encryption))
+			v.etag = hex.EncodeToString(sum[:])
+		}
+	}
+
+	if b.versioned {
+		v.id = s.nextId()
+	} else {
+		v.id = "null"
+		versions := b.objects[key][:0]
+		for _, old := range b.objects[key] {
+			if old.id != "null" {
+				versions = append(versions, old)
+			}
+		}
+		b.objects[key] = versions
+	}
+
+	b.objects[key] = append(b.objects[key], v)
+	return v
+}
+
+func (s *Server) getObject(w http.ResponseWriter, r *http.Request, b *bucket, key string) {
+	v := b.current(key)
+	if v == nil {
+		writeError(w, http.StatusNotFound, "NoSuchKey", "The specified key does not exist")
+		return
+	}
+
+	w.Header().Set("ETag", `"`+v.etag+`"`)
+	w.Header().Set("Content-Type", v.contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(v.data)))
+	w.Header().Set("Last-Modified", v.lastModified.Format(http.TimeFormat))
+	if v.encryption != "" {
+		w.Header().Set("X-Amz-Server-Side-Encryption", v.encryption)
+	}
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodGet {
+		w.Write(v.data)
+	}
+}
+
+func (s *Server) deleteObject(w http.ResponseWriter, query url.Values, b *bucket, key string) {
+	if query.Has("versionId") {
+		versionId := query.Get("versionId")
+		versions := b.objects[key]
+		for i, v := range versions {
+			if v.id == versionId {
+				b.objects[key] = append(versions[:i], versions[i+1:]...)
+				if len(b.objects[key]) == 0 {
+					delete(b.objects, key)
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+		writeError(w, http.StatusNotFound, "NoSuchVersion", "The specified version does not exist")
+		return
+	}
+
+	if !b.versioned {
+		delete(b.objects, key)
+	} else if b.current(key) != nil {
+		s.store(b, key, &version{deleteMarker: true})
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) initiateUpload(w http.ResponseWriter, r *http.Request, bucketName, key string) {
+	// Upload ids carry '+', '/' and '=' like the base64 ids of S3, so they
+	// only round-trip when escaped in query strings.
+	id := "upload+" + s.nextId() + "/osetest=="
+	s.uploads[id] = &upload{
+		bucket:      bucketName,
+		key:         key,
+		contentType: r.Header.Get("Content-Type"),
+		parts:       map[int][]byte{},
+	}
+
+	writeJSON(w, map[string]string{"bucket": bucketName, "key": key, "uploadId": id})
+}
+
+func (s *Server) uploadPart(w http.ResponseWriter, r *http.Request, query url.Values) {
+	u, ok := s.uploads[query.Get("uploadId")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "NoSuchUpload", "The specified upload does not exist")
+		return
+	}
+	partNumber, err := strconv.Atoi(query.Get("partNumber"))
+	if err != nil || partNumber < 1 {
+		writeError(w, http.StatusBadRequest, "InvalidArgument", "Invalid partNumber")
+		return
+	}
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "IncompleteBody", err.Error())
+		return
+	}
+	u.parts[partNumber] = data
+
+	sum := md5.Sum(data)
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:])+`"`)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) abortUpload(w http.ResponseWriter, uploadId string) {
+	if _, ok := s.uploads[uploadId]; !ok {
+		writeError(w, http.StatusNotFound, "NoSuchUpload", "The specified upload does not exist")
+		return
+	}
+
+	delete(s.uploads, uploadId)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) completeUpload(w http.ResponseWriter, r *http.Request, b *bucket, key, uploadId string) {
+	u, ok := s.uploads[uploadId]
+	if !ok || u.key != key {
+		writeError(w, http.StatusNotFound, "NoSuchUpload", "The specified upload does not exist")
+		return
+	}
+
+	var complete struct {
+		Parts []struct {
+			PartNumber int    `json:"partNumber"`
+			ETag       string `json:"etag"`
+		} `json:"parts"`
+	}
+	if !readJSON(w, r, &complete) {
+		return
+	}
+
+	var data []byte
+	sums := md5.New()
+	for i, part := range complete.Parts {
+		content, ok := u.parts[part.PartNumber]
+		sum := md5.Sum(content)
+		if !ok || part.PartNumber != i+1 || hex.EncodeToString(sum[:]) != part.ETag {
+			writeError(w, http.StatusBadRequest, "InvalidPart", fmt.Sprintf("Part %d was not uploaded or its ETag does not match", part.PartNumber))
+			return
+		}
+		data = append(data, content...)
+		sums.Write(sum[:])
+	}
+
+	delete(s.uploads, uploadId)
+	s.store(b, key, &version{
+		data:        data,
+		contentType: u.contentType,
+		etag:        fmt.Sprintf("%s-%d", hex.EncodeToString(sums.Sum(nil)), len(complete.Parts)),
+	})
+
+	writeJSON(w, map[string]string{"bucket": u.bucket, "key": key})
+}
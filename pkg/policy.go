@@ -0,0 +1,136 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// PolicyVersion is the current version of the policy language.
+const PolicyVersion = "2012-10-17"
+
+// PolicyDocument is an IAM-style bucket policy. The fields of a statement
+// that accept either a single value or a list are kept as decoded, so
+// statements of existing documents are re-encoded unchanged.
+type PolicyDocument struct {
+	Version    string            `json:"Version,omitempty"`
+	Id         string            `json:"Id,omitempty"`
+	Statements []PolicyStatement `json:"Statement"`
+}
+
+// UnmarshalJSON decodes the document. Like in IAM, Statement is either a list
+// of statements or a single statement.
+func (p *PolicyDocument) UnmarshalJSON(data []byte) error {
+	type policyDocument PolicyDocument
+	var document struct {
+		policyDocument
+		Statement json.RawMessage `json:"Statement"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return err
+	}
+
+	*p = PolicyDocument(document.policyDocument)
+
+	statement := bytes.TrimSpace(document.Statement)
+	if len(statement) > 0 && statement[0] == '{' {
+		var single PolicyStatement
+		if err := json.Unmarshal(statement, &single); err != nil {
+			return err
+		}
+		p.Statements = []PolicyStatement{single}
+		return nil
+	}
+	if len(statement) > 0 {
+		return json.Unmarshal(statement, &p.Statements)
+	}
+
+	return nil
+}
+
+type PolicyStatement struct {
+	Sid          string                            `json:"Sid,omitempty"`
+	Effect       string                            `json:"Effect,omitempty"`
+	Principal    interface{}                       `json:"Principal,omitempty"`
+	NotPrincipal interface{}                       `json:"NotPrincipal,omitempty"`
+	Action       interface{}                       `json:"Action,omitempty"`
+	NotAction    interface{}                       `json:"NotAction,omitempty"`
+	Resource     interface{}                       `json:"Resource,omitempty"`
+	NotResource  interface{}                       `json:"NotResource,omitempty"`
+	Condition    map[string]map[string]interface{} `json:"Condition,omitempty"`
+}
+
+// PolicyActions are the S3 actions the Object Storage Extension evaluates in
+// bucket policies.
+var PolicyActions = []string{
+	"s3:AbortMultipartUpload",
+	"s3:CreateBucket",
+	"s3:DeleteBucket",
+	"s3:DeleteBucketPolicy",
+	"s3:DeleteBucketWebsite",
+	"s3:DeleteObject",
+	"s3:DeleteObjectTagging",
+	"s3:DeleteObjectVersion",
+	"s3:DeleteObjectVersionTagging",
+	"s3:GetBucketAcl",
+	"s3:GetBucketCORS",
+	"s3:GetBucketLocation",
+	"s3:GetBucketLogging",
+	"s3:GetBucketNotification",
+	"s3:GetBucketPolicy",
+	"s3:GetBucketTagging",
+	"s3:GetBucketVersioning",
+	"s3:GetBucketWebsite",
+	"s3:GetEncryptionConfiguration",
+	"s3:GetLifecycleConfiguration",
+	"s3:GetObject",
+	"s3:GetObjectAcl",
+	"s3:GetObjectTagging",
+	"s3:GetObjectVersion",
+	"s3:GetObjectVersionAcl",
+	"s3:GetObjectVersionTagging",
+	"s3:GetReplicationConfiguration",
+	"s3:ListAllMyBuckets",
+	"s3:ListBucket",
+	"s3:ListBucketMultipartUploads",
+	"s3:ListBucketVersions",
+	"s3:ListMultipartUploadParts",
+	"s3:PutBucketAcl",
+	"s3:PutBucketCORS",
+	"s3:PutBucketLogging",
+	"s3:PutBucketNotification",
+	"s3:PutBucketPolicy",
+	"s3:PutBucketTagging",
+	"s3:PutBucketVersioning",
+	"s3:PutBucketWebsite",
+	"s3:PutEncryptionConfiguration",
+	"s3:PutLifecycleConfiguration",
+	"s3:PutObject",
+	"s3:PutObjectAcl",
+	"s3:PutObjectTagging",
+	"s3:PutObjectVersionAcl",
+	"s3:PutObjectVersionTagging",
+	"s3:PutReplicationConfiguration",
+	"s3:RestoreObject",
+}
+
+// IsPolicyAction reports whether action names at least one of PolicyActions.
+// Like in IAM, actions are case insensitive and may contain the * and ?
+// wildcards.
+func IsPolicyAction(action string) bool {
+	pattern := regexp.QuoteMeta(action)
+	pattern = strings.ReplaceAll(pattern, `\*`, ".*")
+	pattern = strings.ReplaceAll(pattern, `\?`, ".")
+	re, err := regexp.Compile("(?i)^" + pattern + "$")
+	if err != nil {
+		return false
+	}
+
+	for _, supported := range PolicyActions {
+		if re.MatchString(supported) {
+			return true
+		}
+	}
+	return false
+}
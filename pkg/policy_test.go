@@ -0,0 +1,64 @@
+package pkg_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/josajunior81/terraform-provider-vcd-object-storage-ext/pkg"
+)
+
+func TestIsPolicyAction(t *testing.T) {
+	tests := map[string]bool{
+		"s3:GetObject":      true,
+		"S3:getobject":      true,
+		"*":                 true,
+		"s3:*":              true,
+		"s3:Get*":           true,
+		"s3:?utObject":      true,
+		"s3:Frobnicate":     false,
+		"s3:Get":            false,
+		"iam:GetUser":       false,
+		"s3:GetObject.*":    false,
+		"s3:PutBucketCORS ": false,
+	}
+
+	for action, want := range tests {
+		if got := pkg.IsPolicyAction(action); got != want {
+			t.Errorf("IsPolicyAction(%q) = %v, want %v", action, got, want)
+		}
+	}
+}
+
+func TestPolicyDocumentUnmarshal(t *testing.T) {
+	statement := pkg.PolicyStatement{
+		Sid:       "PublicRead",
+		Effect:    "Allow",
+		Principal: "*",
+		Action:    "s3:GetObject",
+		Resource:  "arn:aws:s3:::b1/*",
+	}
+	wanted := pkg.PolicyDocument{Version: pkg.PolicyVersion, Statements: []pkg.PolicyStatement{statement}}
+
+	tests := map[string]string{
+		"list":             `{"Version":"2012-10-17","Statement":[{"Sid":"PublicRead","Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::b1/*"}]}`,
+		"single statement": `{"Version":"2012-10-17","Statement": {"Sid":"PublicRead","Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::b1/*"}}`,
+	}
+	for name, policy := range tests {
+		var document pkg.PolicyDocument
+		if err := json.Unmarshal([]byte(policy), &document); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(document, wanted) {
+			t.Errorf("%s: decoded %+v, want %+v", name, document, wanted)
+		}
+	}
+
+	var document pkg.PolicyDocument
+	if err := json.Unmarshal([]byte(`{"Version":"2012-10-17"}`), &document); err != nil || document.Statements != nil {
+		t.Errorf("document without statements decoded %+v, %v", document, err)
+	}
+	if err := json.Unmarshal([]byte(`{"Statement":"s3:GetObject"}`), &document); err == nil {
+		t.Error("a string statement was accepted")
+	}
+}
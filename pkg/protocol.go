@@ -0,0 +1,242 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Protocol selects the API dialect S3Client speaks.
+type Protocol string
+
+const (
+	// ProtocolOSE is the proprietary JSON API of the Object Storage Extension, under api/v1/s3.
+	ProtocolOSE Protocol = "ose"
+	// ProtocolS3 is the standard S3 REST/XML API, as served by the OSE S3 endpoint or MinIO.
+	ProtocolS3 Protocol = "s3"
+)
+
+// Addressing selects how buckets are addressed by ProtocolS3.
+type Addressing string
+
+const (
+	// AddressingPath puts the bucket in the path: https://host/bucket/key.
+	AddressingPath Addressing = "path"
+	// AddressingVirtualHosted puts the bucket in the host name: https://bucket.host/key.
+	AddressingVirtualHosted Addressing = "virtual-hosted"
+)
+
+// protocol builds the URLs and encodes the payloads of one API dialect. The
+// payload types in types.go carry both json and xml tags, so every operation
+// is written once and only the bucket level calls that have no common shape
+// are implemented per protocol.
+type protocol interface {
+	// url returns the endpoint of resource, a bucket or bucket/key, or of the
+	// service when resource is empty.
+	url(resource, query string) string
+	contentType() string
+	marshal(v interface{}) ([]byte, error)
+	unmarshal(data []byte, v interface{}) error
+
+	getBucket(ctx context.Context, s S3Client, name string) (*Bucket, error)
+	createBucketConfiguration(name, region string) interface{}
+	// emptyBucket removes every object and object version from the bucket.
+	emptyBucket(ctx context.Context, s S3Client, name string) error
+}
+
+var (
+	_ protocol = oseProtocol{}
+	_ protocol = xmlProtocol{}
+)
+
+// WithProtocol selects the API dialect and, for ProtocolS3, the bucket
+// addressing style. The default is ProtocolOSE.
+func WithProtocol(p Protocol, addressing Addressing) S3ClientOption {
+	return func(s *S3Client) {
+		switch p {
+		case ProtocolS3:
+			s.protocol = xmlProtocol{endpoint: endpoint(s.s3Url), virtualHosted: addressing == AddressingVirtualHosted}
+		default:
+			s.protocol = oseProtocol{endpoint: endpoint(s.s3Url), path: s.path}
+		}
+	}
+}
+
+// endpoint returns s3Url with a scheme, https unless one is given.
+func endpoint(s3Url string) string {
+	s3Url = strings.TrimSuffix(s3Url, "/")
+	if strings.HasPrefix(s3Url, "http://") || strings.HasPrefix(s3Url, "https://") {
+		return s3Url
+	}
+	return "https://" + s3Url
+}
+
+type oseProtocol struct {
+	endpoint string
+	path     string
+}
+
+func (p oseProtocol) url(resource, query string) string {
+	if query != "" {
+		return p.endpoint + "/" + p.path + "/" + resource + "?" + query
+	}
+	return p.endpoint + "/" + p.path + "/" + resource
+}
+
+func (oseProtocol) contentType() string {
+	return "application/json"
+}
+
+func (oseProtocol) marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (oseProtocol) unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (oseProtocol) getBucket(ctx context.Context, s S3Client, name string) (*Bucket, error) {
+	bucketStr, err := s.doRequest(ctx, http.MethodGet, s.mountUrl(name, "max-keys=1"), "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var bucketObj *Bucket
+	if err := json.Unmarshal([]byte(bucketStr), &bucketObj); err != nil {
+		return nil, fmt.Errorf("unmarshalling bucket %s: %w", name, err)
+	}
+
+	return bucketObj, nil
+}
+
+func (oseProtocol) createBucketConfiguration(name, region string) interface{} {
+	return map[string]string{"name": name, "locationConstraint": region}
+}
+
+// emptyBucket asks the OSE to purge the bucket, including every version. The
+// purge runs asynchronously, so the bucket is polled until it is empty.
+func (oseProtocol) emptyBucket(ctx context.Context, s S3Client, name string) error {
+	payload := `{
+		"quiet": true,
+		"removeAll": true,
+		"deleteVersion": true,
+		"tryAsync": true
+	}`
+
+	if _, err := s.doRequest(ctx, http.MethodPost, s.mountUrl(name, "delete"), payload, nil); err != nil {
+		return err
+	}
+
+	return s.waitBucketEmpty(ctx, name)
+}
+
+type xmlProtocol struct {
+	endpoint      string
+	virtualHosted bool
+}
+
+func (p xmlProtocol) url(resource, query string) string {
+	u := p.endpoint + "/" + resource
+	if p.virtualHosted && resource != "" {
+		bucket, key, _ := strings.Cut(resource, "/")
+		scheme, host, _ := strings.Cut(p.endpoint, "://")
+		u = scheme + "://" + bucket + "." + host + "/" + key
+	}
+	if query != "" {
+		return u + "?" + query
+	}
+	return u
+}
+
+func (xmlProtocol) contentType() string {
+	return "application/xml"
+}
+
+func (xmlProtocol) marshal(v interface{}) ([]byte, error) {
+	return xml.Marshal(v)
+}
+
+func (xmlProtocol) unmarshal(data []byte, v interface{}) error {
+	return xml.Unmarshal(data, v)
+}
+
+// getBucket checks the bucket exists and reads its owner from the ACL, the
+// closest the S3 API has to the OSE bucket document.
+func (xmlProtocol) getBucket(ctx context.Context, s S3Client, name string) (*Bucket, error) {
+	resp, err := s.send(ctx, func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodHead, s.mountUrl(name, ""), nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	aclStr, err := s.doRequest(ctx, http.MethodGet, s.mountUrl(name, "acl"), "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var acl AccessControlPolicy
+	if err := xml.Unmarshal([]byte(aclStr), &acl); err != nil {
+		return nil, fmt.Errorf("unmarshalling ACL of bucket %s: %w", name, err)
+	}
+
+	tenant, _, _ := strings.Cut(acl.Owner.Id, "|")
+	return &Bucket{
+		Name:   name,
+		Tenant: tenant,
+		S3Href: s.mountUrl(name, ""),
+		Owner:  acl.Owner,
+	}, nil
+}
+
+func (xmlProtocol) createBucketConfiguration(_, region string) interface{} {
+	if region == "" {
+		return nil
+	}
+	return createBucketConfiguration{LocationConstraint: region}
+}
+
+// emptyBucket lists every object version and delete marker and removes them
+// in batches, since the S3 API has no server-side purge.
+func (p xmlProtocol) emptyBucket(ctx context.Context, s S3Client, name string) error {
+	return s.listObjectVersions(ctx, name, "", func(objects []objectIdentity) error {
+		payload, err := xml.Marshal(deleteObjects{Quiet: true, Objects: objects})
+		if err != nil {
+			return err
+		}
+		_, err = s.doRequest(ctx, http.MethodPost, s.mountUrl(name, "delete"), string(payload), nil)
+		return err
+	})
+}
+
+type listBucketsResult struct {
+	Buckets []Bucket `json:"buckets" xml:"Buckets>Bucket"`
+}
+
+type createBucketConfiguration struct {
+	XMLName            xml.Name `xml:"CreateBucketConfiguration"`
+	LocationConstraint string   `xml:"LocationConstraint"`
+}
+
+type listVersionsResult struct {
+	IsTruncated         bool             `json:"isTruncated" xml:"IsTruncated"`
+	NextKeyMarker       string           `json:"nextKeyMarker" xml:"NextKeyMarker"`
+	NextVersionIdMarker string           `json:"nextVersionIdMarker" xml:"NextVersionIdMarker"`
+	Versions            []objectIdentity `json:"versions" xml:"Version"`
+	DeleteMarkers       []objectIdentity `json:"deleteMarkers" xml:"DeleteMarker"`
+}
+
+type objectIdentity struct {
+	Key       string `json:"key" xml:"Key"`
+	VersionId string `json:"versionId,omitempty" xml:"VersionId,omitempty"`
+}
+
+type deleteObjects struct {
+	XMLName xml.Name         `xml:"Delete"`
+	Quiet   bool             `xml:"Quiet"`
+	Objects []objectIdentity `xml:"Object"`
+}
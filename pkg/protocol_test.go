@@ -0,0 +1,377 @@
+package pkg_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/josajunior81/terraform-provider-vcd-object-storage-ext/pkg"
+)
+
+// s3Response is a canned answer of s3Fake.
+type s3Response struct {
+	status int
+	etag   string
+	body   string
+}
+
+// s3Request is a request received by s3Fake.
+type s3Request struct {
+	method, uri, contentType, body string
+}
+
+// s3Fake answers the requests of a ProtocolS3 client with the XML documents
+// of the S3 API reference, keyed by method and request URI, and records the
+// requests it received.
+type s3Fake struct {
+	mu        sync.Mutex
+	responses map[string]s3Response
+	requests  []s3Request
+}
+
+func newS3Fake(t *testing.T, responses map[string]s3Response) (*s3Fake, pkg.S3Client) {
+	t.Helper()
+
+	fake := &s3Fake{responses: responses}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+
+		fake.requests = append(fake.requests, s3Request{r.Method, r.RequestURI, r.Header.Get("Content-Type"), string(body)})
+		resp, ok := fake.responses[r.Method+" "+r.RequestURI]
+		if !ok {
+			t.Errorf("unexpected request %s %s", r.Method, r.RequestURI)
+			w.WriteHeader(http.StatusNotImplemented)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/xml")
+		if resp.etag != "" {
+			w.Header().Set("ETag", resp.etag)
+		}
+		if resp.status != 0 {
+			w.WriteHeader(resp.status)
+		}
+		io.WriteString(w, resp.body)
+	}))
+	t.Cleanup(server.Close)
+
+	policy := pkg.DefaultRetryPolicy()
+	policy.MaxAttempts = 1
+
+	return fake, pkg.NewS3ClientWithKeys(server.URL, "us-east-1", "access", "secret", "",
+		pkg.WithRetryPolicy(policy),
+		pkg.WithProtocol(pkg.ProtocolS3, pkg.AddressingPath),
+		pkg.WithMultipartConfig(pkg.MultipartConfig{Threshold: 6 << 20, PartSize: 5 << 20, Concurrency: 1}),
+	)
+}
+
+// sent returns the request received with method and uri.
+func (f *s3Fake) sent(t *testing.T, method, uri string) s3Request {
+	t.Helper()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, r := range f.requests {
+		if r.method == method && r.uri == uri {
+			return r
+		}
+	}
+	t.Fatalf("%s %s was not sent, requests are %v", method, uri, f.requests)
+	return s3Request{}
+}
+
+const aclDocument = `<?xml version="1.0" encoding="UTF-8"?>
+<AccessControlPolicy xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Owner>
+    <ID>tenant|owner</ID>
+    <DisplayName>owner</DisplayName>
+  </Owner>
+  <AccessControlList>
+    <Grant>
+      <Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="CanonicalUser">
+        <ID>tenant|owner</ID>
+        <DisplayName>owner</DisplayName>
+      </Grantee>
+      <Permission>FULL_CONTROL</Permission>
+    </Grant>
+    <Grant>
+      <Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="Group">
+        <URI>http://acs.amazonaws.com/groups/global/AllUsers</URI>
+      </Grantee>
+      <Permission>READ</Permission>
+    </Grant>
+  </AccessControlList>
+</AccessControlPolicy>`
+
+func TestXMLListBuckets(t *testing.T) {
+	_, client := newS3Fake(t, map[string]s3Response{
+		"GET /": {body: `<?xml version="1.0" encoding="UTF-8"?>
+<ListAllMyBucketsResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Owner>
+    <ID>bcaf1ffd86f461ca5fb16fd081034f</ID>
+    <DisplayName>webfile</DisplayName>
+  </Owner>
+  <Buckets>
+    <Bucket>
+      <Name>quotes</Name>
+      <CreationDate>2006-02-03T16:45:09.000Z</CreationDate>
+    </Bucket>
+    <Bucket>
+      <Name>samples</Name>
+      <CreationDate>2006-02-03T16:41:58.000Z</CreationDate>
+    </Bucket>
+  </Buckets>
+</ListAllMyBucketsResult>`},
+	})
+
+	names, err := client.ListBuckets(context.Background())
+	if err != nil {
+		t.Fatalf("ListBuckets: %v", err)
+	}
+	if want := []string{"quotes", "samples"}; !slices.Equal(names, want) {
+		t.Errorf("ListBuckets = %v, want %v", names, want)
+	}
+}
+
+func TestXMLBucketAcl(t *testing.T) {
+	fake, client := newS3Fake(t, map[string]s3Response{
+		"GET /b1?acl": {body: aclDocument},
+		"PUT /b1?acl": {},
+	})
+	ctx := context.Background()
+
+	acl, err := client.GetBucketAcl(ctx, "b1")
+	if err != nil {
+		t.Fatalf("GetBucketAcl: %v", err)
+	}
+	if acl.Owner.Id != "tenant|owner" || len(acl.Grants) != 2 {
+		t.Fatalf("GetBucketAcl = %+v", acl)
+	}
+	if g := acl.Grants[0]; g.Grantee.Id != "tenant|owner" || g.Grantee.Type != "CanonicalUser" || g.Permission != "FULL_CONTROL" {
+		t.Errorf("grant 0 = %+v", g)
+	}
+	if g := acl.Grants[1]; g.Grantee.Uri != "http://acs.amazonaws.com/groups/global/AllUsers" || g.Grantee.Type != "Group" || g.Permission != "READ" {
+		t.Errorf("grant 1 = %+v", g)
+	}
+
+	// The grants read are sent back typed, next to the added ones.
+	if err := client.EnsureLogDeliveryGrants(ctx, "b1"); err != nil {
+		t.Fatalf("EnsureLogDeliveryGrants: %v", err)
+	}
+	const grantee = `<Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type=`
+	want := `<AccessControlPolicy><Owner><ID>tenant|owner</ID><DisplayName>owner</DisplayName></Owner><AccessControlList>` +
+		`<Grant>` + grantee + `"CanonicalUser"><ID>tenant|owner</ID></Grantee><Permission>FULL_CONTROL</Permission></Grant>` +
+		`<Grant>` + grantee + `"Group"><URI>http://acs.amazonaws.com/groups/global/AllUsers</URI></Grantee><Permission>READ</Permission></Grant>` +
+		`<Grant>` + grantee + `"Group"><URI>http://acs.amazonaws.com/groups/s3/LogDelivery</URI></Grantee><Permission>WRITE</Permission></Grant>` +
+		`<Grant>` + grantee + `"Group"><URI>http://acs.amazonaws.com/groups/s3/LogDelivery</URI></Grantee><Permission>READ_ACP</Permission></Grant>` +
+		`</AccessControlList></AccessControlPolicy>`
+	sent := fake.sent(t, http.MethodPut, "/b1?acl")
+	if sent.body != want {
+		t.Errorf("PUT acl body =\n%s\nwant\n%s", sent.body, want)
+	}
+	if sent.contentType != "application/xml" {
+		t.Errorf("PUT acl content type = %q", sent.contentType)
+	}
+}
+
+func TestXMLBucketTags(t *testing.T) {
+	fake, client := newS3Fake(t, map[string]s3Response{
+		"GET /b1?tagging": {body: `<?xml version="1.0" encoding="UTF-8"?>
+<Tagging xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <TagSet>
+    <Tag>
+      <Key>Project</Key>
+      <Value>Project One</Value>
+    </Tag>
+    <Tag>
+      <Key>User</Key>
+      <Value>jsmith</Value>
+    </Tag>
+  </TagSet>
+</Tagging>`},
+		"DELETE /b1?tagging": {status: http.StatusNoContent},
+		"PUT /b1?tagging":    {},
+	})
+	ctx := context.Background()
+
+	tags, err := client.GetBucketTags(ctx, "b1")
+	if err != nil {
+		t.Fatalf("GetBucketTags: %v", err)
+	}
+	if want := []pkg.Tag{{Key: "Project", Value: "Project One"}, {Key: "User", Value: "jsmith"}}; !slices.Equal(tags, want) {
+		t.Errorf("GetBucketTags = %v, want %v", tags, want)
+	}
+
+	err = client.BucketTags(ctx, "b1", []pkg.Tag{{Key: "env", Value: "a & b"}})
+	if err != nil {
+		t.Fatalf("BucketTags: %v", err)
+	}
+	want := `<Tagging><TagSet><Tag><Key>env</Key><Value>a &amp; b</Value></Tag></TagSet></Tagging>`
+	if sent := fake.sent(t, http.MethodPut, "/b1?tagging"); sent.body != want {
+		t.Errorf("PUT tagging body = %s, want %s", sent.body, want)
+	}
+}
+
+func TestXMLErrors(t *testing.T) {
+	_, client := newS3Fake(t, map[string]s3Response{
+		"GET /missing?acl": {status: http.StatusNotFound, body: `<?xml version="1.0" encoding="UTF-8"?>
+<Error>
+  <Code>NoSuchBucket</Code>
+  <Message>The specified bucket does not exist</Message>
+  <BucketName>missing</BucketName>
+  <RequestId>4442587FB7D0A2F9</RequestId>
+  <HostId>4+Bsz8o+3/BOC6rd5zIBBCk3gHJMTg+dtaCHKTrwWSFOYGbOXsE2zvYYqBQRgEqK</HostId>
+</Error>`},
+		"GET /b1?tagging": {status: http.StatusNotFound, body: `<Error><Code>NoSuchTagSet</Code><Message>The TagSet does not exist</Message></Error>`},
+	})
+	ctx := context.Background()
+
+	_, err := client.GetBucketAcl(ctx, "missing")
+	var apiErr *pkg.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("GetBucketAcl of a missing bucket = %v, want an APIError", err)
+	}
+	if !pkg.IsNotFound(err) || apiErr.Code != "NoSuchBucket" || apiErr.Message != "The specified bucket does not exist" || apiErr.RequestId != "4442587FB7D0A2F9" {
+		t.Errorf("APIError = %+v", apiErr)
+	}
+
+	if tags, err := client.GetBucketTags(ctx, "b1"); err != nil || len(tags) != 0 {
+		t.Errorf("GetBucketTags without a tag set = %v, %v", tags, err)
+	}
+}
+
+func TestXMLMultipartUpload(t *testing.T) {
+	fake, client := newS3Fake(t, map[string]s3Response{
+		"POST /b1/large.bin?uploads": {body: `<?xml version="1.0" encoding="UTF-8"?>
+<InitiateMultipartUploadResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Bucket>b1</Bucket>
+  <Key>large.bin</Key>
+  <UploadId>VXBsb2FkIElE+ZWx2aW5nJ3Mg/bXkubW92aWUubTJ0cyB1cGxvYWQ=</UploadId>
+</InitiateMultipartUploadResult>`},
+		"PUT /b1/large.bin?partNumber=1&uploadId=VXBsb2FkIElE%2BZWx2aW5nJ3Mg%2FbXkubW92aWUubTJ0cyB1cGxvYWQ%3D": {etag: `"7778aef83f66abc1fa1e8477f296d394"`},
+		"PUT /b1/large.bin?partNumber=2&uploadId=VXBsb2FkIElE%2BZWx2aW5nJ3Mg%2FbXkubW92aWUubTJ0cyB1cGxvYWQ%3D": {etag: `"aaaa18db4cc2f85cedef654fccc4a4x8"`},
+		"POST /b1/large.bin?uploadId=VXBsb2FkIElE%2BZWx2aW5nJ3Mg%2FbXkubW92aWUubTJ0cyB1cGxvYWQ%3D": {body: `<?xml version="1.0" encoding="UTF-8"?>
+<CompleteMultipartUploadResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Location>http://b1.s3.amazonaws.com/large.bin</Location>
+  <Bucket>b1</Bucket>
+  <Key>large.bin</Key>
+  <ETag>"3858f62230ac3c915f300c664312c11f-2"</ETag>
+</CompleteMultipartUploadResult>`},
+	})
+
+	content := bytes.Repeat([]byte{0}, 6<<20)
+	if err := client.UploadObject(context.Background(), "b1", "large.bin", writeFile(t, content), true); err != nil {
+		t.Fatalf("UploadObject: %v", err)
+	}
+
+	if sent := fake.sent(t, http.MethodPost, "/b1/large.bin?uploads"); sent.contentType != "application/octet-stream" {
+		t.Errorf("initiate content type = %q, want the object content type", sent.contentType)
+	}
+	want := `<CompleteMultipartUpload>` +
+		`<Part><PartNumber>1</PartNumber><ETag>7778aef83f66abc1fa1e8477f296d394</ETag></Part>` +
+		`<Part><PartNumber>2</PartNumber><ETag>aaaa18db4cc2f85cedef654fccc4a4x8</ETag></Part>` +
+		`</CompleteMultipartUpload>`
+	if sent := fake.sent(t, http.MethodPost, "/b1/large.bin?uploadId=VXBsb2FkIElE%2BZWx2aW5nJ3Mg%2FbXkubW92aWUubTJ0cyB1cGxvYWQ%3D"); sent.body != want {
+		t.Errorf("complete body =\n%s\nwant\n%s", sent.body, want)
+	}
+}
+
+func TestXMLObjectVersions(t *testing.T) {
+	fake, client := newS3Fake(t, map[string]s3Response{
+		"GET /b1?versions&prefix=my-image.jpg": {body: `<?xml version="1.0" encoding="UTF-8"?>
+<ListVersionsResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Name>b1</Name>
+  <Prefix>my-image.jpg</Prefix>
+  <KeyMarker></KeyMarker>
+  <VersionIdMarker></VersionIdMarker>
+  <NextKeyMarker>my-image.jpg</NextKeyMarker>
+  <NextVersionIdMarker>3/L4kqtJl40Nr8X8gdRQBpUMLUo</NextVersionIdMarker>
+  <MaxKeys>2</MaxKeys>
+  <IsTruncated>true</IsTruncated>
+  <DeleteMarker>
+    <Key>my-image.jpg</Key>
+    <VersionId>03jpff543dhffds434rfdsFDN943fdsFkdmqnh892</VersionId>
+    <IsLatest>true</IsLatest>
+    <LastModified>2009-10-15T17:50:30.000Z</LastModified>
+  </DeleteMarker>
+  <Version>
+    <Key>my-image.jpg</Key>
+    <VersionId>3/L4kqtJl40Nr8X8gdRQBpUMLUo</VersionId>
+    <IsLatest>false</IsLatest>
+    <LastModified>2009-10-12T17:50:30.000Z</LastModified>
+    <ETag>"fba9dede5f27731c9771645a39863328"</ETag>
+    <Size>434234</Size>
+    <StorageClass>STANDARD</StorageClass>
+  </Version>
+</ListVersionsResult>`},
+		"GET /b1?versions&prefix=my-image.jpg&key-marker=my-image.jpg&version-id-marker=3%2FL4kqtJl40Nr8X8gdRQBpUMLUo": {body: `<?xml version="1.0" encoding="UTF-8"?>
+<ListVersionsResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Name>b1</Name>
+  <Prefix>my-image.jpg</Prefix>
+  <IsTruncated>false</IsTruncated>
+  <Version>
+    <Key>my-image.jpg</Key>
+    <VersionId>QUpfdndhfd8438MNFDN93jdnJFkdmqnh893</VersionId>
+    <IsLatest>false</IsLatest>
+  </Version>
+  <Version>
+    <Key>my-image.jpg.bak</Key>
+    <VersionId>UIORUnfndfhnw89493jJFJ</VersionId>
+    <IsLatest>true</IsLatest>
+  </Version>
+</ListVersionsResult>`},
+		"DELETE /b1/my-image.jpg?versionId=03jpff543dhffds434rfdsFDN943fdsFkdmqnh892": {status: http.StatusNoContent},
+		"DELETE /b1/my-image.jpg?versionId=3%2FL4kqtJl40Nr8X8gdRQBpUMLUo":             {status: http.StatusNoContent},
+		"DELETE /b1/my-image.jpg?versionId=QUpfdndhfd8438MNFDN93jdnJFkdmqnh893":       {status: http.StatusNoContent},
+	})
+
+	if err := client.DeleteObject(context.Background(), "b1", "my-image.jpg", true); err != nil {
+		t.Fatalf("DeleteObject: %v", err)
+	}
+	if n := len(fake.requests); n != 5 {
+		t.Errorf("sent %d requests, want 2 listings and 3 deletions of my-image.jpg only", n)
+	}
+}
+
+func TestXMLDeleteBucket(t *testing.T) {
+	fake, client := newS3Fake(t, map[string]s3Response{
+		"GET /b1?versions": {body: `<?xml version="1.0" encoding="UTF-8"?>
+<ListVersionsResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Name>b1</Name>
+  <IsTruncated>false</IsTruncated>
+  <Version>
+    <Key>sample.jpg</Key>
+    <VersionId>null</VersionId>
+  </Version>
+  <DeleteMarker>
+    <Key>dir/a &amp; b.txt</Key>
+    <VersionId>UIORUnfndfhnw89493jJFJ</VersionId>
+  </DeleteMarker>
+</ListVersionsResult>`},
+		"POST /b1?delete": {body: `<?xml version="1.0" encoding="UTF-8"?>
+<DeleteResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/"></DeleteResult>`},
+		"DELETE /b1": {status: http.StatusNoContent},
+	})
+
+	if err := client.DeleteBucket(context.Background(), "b1"); err != nil {
+		t.Fatalf("DeleteBucket: %v", err)
+	}
+
+	want := `<Delete><Quiet>true</Quiet>` +
+		`<Object><Key>sample.jpg</Key><VersionId>null</VersionId></Object>` +
+		`<Object><Key>dir/a &amp; b.txt</Key><VersionId>UIORUnfndfhnw89493jJFJ</VersionId></Object>` +
+		`</Delete>`
+	if sent := fake.sent(t, http.MethodPost, "/b1?delete"); sent.body != want {
+		t.Errorf("delete body =\n%s\nwant\n%s", sent.body, want)
+	}
+}
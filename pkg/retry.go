@@ -0,0 +1,84 @@
+package pkg
+
+import (
+	"math/rand"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how S3Client retries failed requests.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry. It doubles on every attempt.
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff delay and any Retry-After sent by the server.
+	MaxDelay time.Duration
+	// Jitter randomizes every delay between half and the full backoff.
+	Jitter bool
+	// RetryableStatusCodes are the response codes that trigger a retry.
+	RetryableStatusCodes []int
+	// RetryableMethods are the HTTP methods that are safe to replay.
+	RetryableMethods []string
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:          5,
+		BaseDelay:            time.Second,
+		MaxDelay:             30 * time.Second,
+		Jitter:               true,
+		RetryableStatusCodes: []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+		RetryableMethods:     []string{http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions},
+	}
+}
+
+// shouldRetry reports whether a request with the given method that ended with
+// resp or err can be attempted again after attempt tries.
+func (p RetryPolicy) shouldRetry(attempt int, method string, resp *http.Response, err error) bool {
+	if attempt >= p.MaxAttempts || !slices.Contains(p.RetryableMethods, method) {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	return slices.Contains(p.RetryableStatusCodes, resp.StatusCode)
+}
+
+// delay returns how long to wait before the retry following attempt,
+// honoring the Retry-After header of resp when present.
+func (p RetryPolicy) delay(attempt int, resp *http.Response) time.Duration {
+	d := p.BaseDelay << (attempt - 1)
+	if d <= 0 || d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if p.Jitter && d > 1 {
+		d = d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+	}
+
+	if retryAfter, ok := parseRetryAfter(resp); ok && retryAfter > d {
+		d = min(retryAfter, p.MaxDelay)
+	}
+
+	return d
+}
+
+// parseRetryAfter reads the Retry-After header, either delay-seconds or an HTTP date.
+func parseRetryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date), true
+	}
+	return 0, false
+}
@@ -0,0 +1,141 @@
+package pkg_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/josajunior81/terraform-provider-vcd-object-storage-ext/pkg"
+)
+
+// newRetryClient returns a client of a server answering every request with
+// handler, and the number of requests the server received.
+func newRetryClient(t *testing.T, policy pkg.RetryPolicy, handler func(w http.ResponseWriter, attempt int32)) (pkg.S3Client, *atomic.Int32) {
+	t.Helper()
+
+	attempts := &atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler(w, attempts.Add(1))
+	}))
+	t.Cleanup(server.Close)
+
+	return pkg.NewS3ClientWithKeys(server.URL, "us-east-1", "access", "secret", "", pkg.WithRetryPolicy(policy)), attempts
+}
+
+func testRetryPolicy() pkg.RetryPolicy {
+	policy := pkg.DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	policy.MaxDelay = 5 * time.Second
+	policy.Jitter = false
+	return policy
+}
+
+func TestRetryAfter(t *testing.T) {
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusServiceUnavailable} {
+		client, attempts := newRetryClient(t, testRetryPolicy(), func(w http.ResponseWriter, attempt int32) {
+			if attempt == 1 {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(status)
+				return
+			}
+			w.Write([]byte(`{"Version":"2012-10-17"}`))
+		})
+
+		start := time.Now()
+		policy, err := client.GetBucketPolicy(context.Background(), "b1")
+		if err != nil {
+			t.Fatalf("GetBucketPolicy after a %d: %v", status, err)
+		}
+		if policy != `{"Version":"2012-10-17"}` {
+			t.Errorf("GetBucketPolicy = %s", policy)
+		}
+		if n := attempts.Load(); n != 2 {
+			t.Errorf("%d: server received %d requests, want 2", status, n)
+		}
+		if elapsed := time.Since(start); elapsed < time.Second {
+			t.Errorf("%d: retried after %s, before the Retry-After of 1s", status, elapsed)
+		}
+	}
+}
+
+func TestRetryAfterCappedByMaxDelay(t *testing.T) {
+	policy := testRetryPolicy()
+	policy.MaxDelay = 10 * time.Millisecond
+
+	client, attempts := newRetryClient(t, policy, func(w http.ResponseWriter, attempt int32) {
+		if attempt == 1 {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("{}"))
+	})
+
+	start := time.Now()
+	if _, err := client.GetBucketPolicy(context.Background(), "b1"); err != nil {
+		t.Fatalf("GetBucketPolicy: %v", err)
+	}
+	if n := attempts.Load(); n != 2 {
+		t.Errorf("server received %d requests, want 2", n)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("retried after %s, want at most the max delay", elapsed)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	policy := testRetryPolicy()
+	policy.MaxAttempts = 3
+
+	client, attempts := newRetryClient(t, policy, func(w http.ResponseWriter, _ int32) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	_, err := client.GetBucketPolicy(context.Background(), "b1")
+	var apiErr *pkg.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("GetBucketPolicy = %v, want a 503 APIError", err)
+	}
+	if n := attempts.Load(); n != 3 {
+		t.Errorf("server received %d requests, want retry_max_attempts 3", n)
+	}
+}
+
+func TestRetryNotRetryable(t *testing.T) {
+	client, attempts := newRetryClient(t, testRetryPolicy(), func(w http.ResponseWriter, _ int32) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	if _, err := client.GetBucketPolicy(context.Background(), "b1"); err == nil {
+		t.Fatal("GetBucketPolicy succeeded on a 500")
+	}
+	if n := attempts.Load(); n != 1 {
+		t.Errorf("server received %d requests, want 1", n)
+	}
+}
+
+func TestRetryContextCancelled(t *testing.T) {
+	client, attempts := newRetryClient(t, testRetryPolicy(), func(w http.ResponseWriter, _ int32) {
+		w.Header().Set("Retry-After", "5")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := client.GetBucketPolicy(ctx, "b1")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("GetBucketPolicy = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("GetBucketPolicy returned %s after the cancellation", elapsed)
+	}
+	if n := attempts.Load(); n != 1 {
+		t.Errorf("server received %d requests, want 1", n)
+	}
+}
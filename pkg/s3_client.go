@@ -2,7 +2,10 @@ package pkg
 
 import (
 	"bytes"
+	"context"
+	"crypto/md5"
 	"crypto/tls"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -10,180 +13,505 @@ import (
 	"net/http"
 	"net/url"
 	"os"
-	"regexp"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/vmware/go-vcloud-director/v2/govcd"
+	"github.com/vmware/go-vcloud-director/v2/types/v56"
 )
 
 const PATH = "api/v1/s3"
 
-func NewS3Client(s3url, region, apiToken, org, vcdUrl string) S3Client {
+// S3ClientOption customizes an S3Client created by NewS3Client.
+type S3ClientOption func(*S3Client)
 
-	client := &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		},
+// WithRetryPolicy sets the policy used to retry failed requests.
+func WithRetryPolicy(policy RetryPolicy) S3ClientOption {
+	return func(s *S3Client) {
+		s.retry = policy
 	}
+}
 
-	u, _ := url.ParseRequestURI(fmt.Sprintf("%s/api", vcdUrl))
+// NewS3Client returns a client that authenticates with a VCD API token,
+// exchanged for bearer tokens against vcdUrl. It fails with ErrInvalidUrl or
+// ErrAuthentication when VCD cannot be reached with the given settings.
+func NewS3Client(s3url, region, apiToken, org, vcdUrl string, opts ...S3ClientOption) (S3Client, error) {
+	s3client := newS3Client(s3url, region, opts...)
 
-	vcdClient := govcd.NewVCDClient(*u, true)
-	_, err := vcdClient.SetApiToken(org, apiToken)
+	u, err := url.ParseRequestURI(fmt.Sprintf("%s/api", vcdUrl))
 	if err != nil {
-		log.Fatal(err)
+		return S3Client{}, fmt.Errorf("%w %q: %w", ErrInvalidUrl, vcdUrl, err)
 	}
 
-	bearerToken, err := vcdClient.GetBearerTokenFromApiToken(org, apiToken)
-	if err != nil {
-		log.Fatal(err)
+	vcdClient := govcd.NewVCDClient(*u, s3client.tlsConfig.InsecureSkipVerify, vcdTLSConfig(s3client.tlsConfig))
+	tokens := newBearerTokenSource(func() (*types.ApiTokenRefresh, error) {
+		return vcdClient.GetBearerTokenFromApiToken(org, apiToken)
+	})
+
+	if _, err := tokens.Token(); err != nil {
+		return S3Client{}, fmt.Errorf("%w for org %s at %s: %w", ErrAuthentication, org, vcdUrl, err)
 	}
+	s3client.auth = tokens
+
+	return s3client, nil
+}
 
+// NewS3ClientWithKeys returns a client that signs every request with AWS
+// Signature Version 4 using the access and secret keys of an OSE S3 user.
+// sessionToken is optional. VCD is never contacted.
+func NewS3ClientWithKeys(s3url, region, accessKey, secretKey, sessionToken string, opts ...S3ClientOption) S3Client {
+	s3client := newS3Client(s3url, region, opts...)
+	s3client.auth = newSigV4Signer(accessKey, secretKey, sessionToken, region)
+
+	return s3client
+}
+
+func newS3Client(s3url, region string, opts ...S3ClientOption) S3Client {
 	s3client := S3Client{
-		client:      client,
-		s3Url:       s3url,
-		bearerToken: bearerToken.AccessToken,
-		path:        PATH,
-		region:      region,
+		s3Url:     s3url,
+		path:      PATH,
+		region:    region,
+		retry:     DefaultRetryPolicy(),
+		multipart: DefaultMultipartConfig(),
+		tlsConfig: &tls.Config{MinVersion: tls.VersionTLS12},
+		protocol:  oseProtocol{endpoint: endpoint(s3url), path: PATH},
+	}
+
+	for _, opt := range opts {
+		opt(&s3client)
+	}
+
+	s3client.client = &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: s3client.tlsConfig.Clone(),
+		},
 	}
 
 	return s3client
 }
 
 func (s S3Client) mountUrl(resource, query string) string {
-	if query != "" {
-		return "https://" + s.s3Url + "/" + s.path + "/" + resource + "?" + query
-	}
-	return "https://" + s.s3Url + "/" + s.path + "/" + resource
+	return s.protocol.url(resource, query)
 }
 
-func (s S3Client) doRequest(method, reqUrl, body string, additionalHeaders map[string]string) (string, error) {
-	var req *http.Request
-	var err error
-	if body != "" {
-		req, err = http.NewRequest(method, reqUrl, bytes.NewBuffer([]byte(body)))
-	} else {
-		req, err = http.NewRequest(method, reqUrl, nil)
-	}
-	if err != nil {
-		log.Printf("Error do request %v", err)
-		return "", err
+// objectResource returns the resource of bucket/key for mountUrl. Every
+// segment of the key is URI encoded the way SigV4 canonicalizes paths, so
+// keys with spaces, reserved characters or a leading "?" reach the Object
+// Storage unchanged and the signed path matches the one sent.
+func objectResource(bucket, key string) string {
+	return bucket + "/" + uriEncode(key, false)
+}
+
+// uriEncode percent-encodes every byte of s except the unreserved characters
+// A-Z, a-z, 0-9, '-', '.', '_' and '~', using upper case hex digits. Slashes
+// are kept unless encodeSlash is set.
+func uriEncode(s string, encodeSlash bool) string {
+	const hex = "0123456789ABCDEF"
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '.', c == '_', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hex[c>>4])
+			b.WriteByte(hex[c&15])
+		}
 	}
 
-	req.Header.Add("Authorization", "Bearer "+s.bearerToken)
-	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("accept", "application/json")
-	for k, v := range additionalHeaders {
-		req.Header.Add(k, v)
+	return b.String()
+}
+
+// send builds a request with newReq, authorizes and sends it, retrying
+// according to the retry policy. newReq is called for every attempt so the
+// body can be replayed. A 401 re-authenticates once and replays the request.
+// Non-2xx responses are returned as *APIError.
+func (s S3Client) send(ctx context.Context, newReq func() (*http.Request, error)) (*http.Response, error) {
+	reauthenticated := false
+	for attempt := 1; ; attempt++ {
+		req, err := newReq()
+		if err != nil {
+			log.Printf("Error do request %v", err)
+			return nil, err
+		}
+
+		if err := s.auth.authorize(req); err != nil {
+			return nil, err
+		}
+
+		resp, err := s.client.Do(req)
+		if err == nil && resp.StatusCode == http.StatusUnauthorized && !reauthenticated && s.auth.refresh(req) {
+			log.Printf("[WARN] %s %s returned 401, refreshing credentials and replaying", req.Method, req.URL)
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			reauthenticated = true
+			attempt--
+			continue
+		}
+		if s.retry.shouldRetry(attempt, req.Method, resp, err) {
+			delay := s.retry.delay(attempt, resp)
+			if err != nil {
+				log.Printf("[WARN] %s %s failed: %s, retrying in %s (attempt %d/%d)", req.Method, req.URL, err, delay, attempt, s.retry.MaxAttempts)
+			} else {
+				log.Printf("[WARN] %s %s returned %d, retrying in %s (attempt %d/%d)", req.Method, req.URL, resp.StatusCode, delay, attempt, s.retry.MaxAttempts)
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+			}
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(delay):
+			}
+			continue
+		}
+
+		if err != nil {
+			log.Printf("Error sending HTTP request: %s", err)
+			return nil, err
+		}
+		if attempt > 1 {
+			log.Printf("[INFO] %s %s completed after %d retries", req.Method, req.URL, attempt-1)
+		}
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			defer resp.Body.Close()
+			respBody, _ := io.ReadAll(resp.Body)
+			apiErr := newAPIError(resp, respBody)
+			log.Printf("Error response: %v", apiErr)
+			return nil, apiErr
+		}
+
+		return resp, nil
 	}
+}
+
+func (s S3Client) doRequest(ctx context.Context, method, reqUrl, body string, additionalHeaders map[string]string) (string, error) {
+	resp, err := s.send(ctx, func() (*http.Request, error) {
+		var req *http.Request
+		var err error
+		if body != "" {
+			req, err = http.NewRequestWithContext(ctx, method, reqUrl, bytes.NewBuffer([]byte(body)))
+		} else {
+			req, err = http.NewRequestWithContext(ctx, method, reqUrl, nil)
+		}
+		if err != nil {
+			return nil, err
+		}
 
-	resp, err := s.client.Do(req)
+		req.Header.Add("Content-Type", s.protocol.contentType())
+		req.Header.Add("accept", s.protocol.contentType())
+		if body != "" {
+			sum := md5.Sum([]byte(body))
+			req.Header.Add("Content-Md5", base64.StdEncoding.EncodeToString(sum[:]))
+		}
+		for k, v := range additionalHeaders {
+			req.Header.Set(k, v)
+		}
+		return req, nil
+	})
 	if err != nil {
-		log.Printf("Error sending HTTP request: %s", err)
 		return "", err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusOK {
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			log.Printf("Error reading HTTP response body: %s", err)
-			return "", err
-		}
-		log.Printf("Body: %s", string(body))
-		return string(body), nil
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Printf("Error reading HTTP response body: %s", err)
+		return "", err
 	}
 
-	return "", nil
+	log.Printf("[TRACE] %s %s response body: %s", method, reqUrl, respBody)
+	return string(respBody), nil
 }
 
-func (s S3Client) doUpload(reqUrl, source string) error {
+// doUpload streams source to reqUrl in a single PUT. The file is re-read from
+// the start on every attempt instead of being buffered in memory.
+func (s S3Client) doUpload(ctx context.Context, reqUrl string, file *os.File, size int64, contentType string) error {
+	resp, err := s.send(ctx, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPut, reqUrl, io.NewSectionReader(file, 0, size))
+		if err != nil {
+			return nil, err
+		}
 
-	file, err := os.ReadFile(source)
+		req.ContentLength = size
+		req.Header.Add("Content-Type", contentType)
+		return req, nil
+	})
 	if err != nil {
-		log.Println(err)
 		return err
 	}
+	defer resp.Body.Close()
 
-	contentType := http.DetectContentType(file)
+	return nil
+}
 
-	log.Println("File content type", contentType)
+// detectContentType sniffs the content type from the first bytes of file.
+func detectContentType(file *os.File) (string, error) {
+	head := make([]byte, 512)
+	n, err := file.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return http.DetectContentType(head[:n]), nil
+}
 
-	req, err := http.NewRequest(http.MethodPut, reqUrl, bytes.NewBuffer(file))
+// ListBuckets returns the names of every bucket visible to the client.
+func (s S3Client) ListBuckets(ctx context.Context) ([]string, error) {
+	resp, err := s.doRequest(ctx, http.MethodGet, s.mountUrl("", ""), "", nil)
 	if err != nil {
-		log.Printf("Error do request %v", err)
-		return err
+		return nil, err
 	}
 
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", s.bearerToken))
-	req.Header.Add("Content-Type", contentType)
+	var list listBucketsResult
+	if err := s.protocol.unmarshal([]byte(resp), &list); err != nil {
+		return nil, fmt.Errorf("unmarshalling buckets: %w", err)
+	}
 
-	resp, err := s.client.Do(req)
-	if err != nil {
-		log.Printf("Error sending HTTP request: %s", err)
-		return err
+	names := make([]string, 0, len(list.Buckets))
+	for _, bucket := range list.Buckets {
+		names = append(names, bucket.Name)
 	}
-	defer resp.Body.Close()
 
-	return nil
+	return names, nil
 }
 
-func (s S3Client) GetBucket(name string) (string, error) {
-	bucketUrl := s.mountUrl(name, "max-keys=1")
+func (s S3Client) GetBucket(ctx context.Context, name string) (string, error) {
+	bucketObj, err := s.protocol.getBucket(ctx, s, name)
+	if err != nil {
+		return "", err
+	}
 
-	resp, err := s.doRequest(http.MethodGet, bucketUrl, "", nil)
+	bucketStr, err := json.Marshal(bucketObj)
+	if err != nil {
+		return "", err
+	}
 
-	return resp, err
+	return string(bucketStr), nil
 }
 
-func (s S3Client) CreateBucket(name string) error {
+func (s S3Client) CreateBucket(ctx context.Context, name string) error {
 	createBucketUrl := s.mountUrl(name, "")
 
-	body := fmt.Sprintf(`{"name":"%s", "locationConstraint":"%s"}`, name, s.region)
+	var body string
+	if configuration := s.protocol.createBucketConfiguration(name, s.region); configuration != nil {
+		payload, err := s.protocol.marshal(configuration)
+		if err != nil {
+			return err
+		}
+		body = string(payload)
+	}
 
-	_, err := s.doRequest(http.MethodPut, createBucketUrl, body, nil)
+	_, err := s.doRequest(ctx, http.MethodPut, createBucketUrl, body, nil)
 
 	return err
 }
 
-func (s S3Client) BucketTags(bucket string, tags []any) error {
+func (s S3Client) BucketTags(ctx context.Context, bucket string, tags []Tag) error {
 	tagsUrl := s.mountUrl(bucket, "tagging")
 
-	s.removeBucketTags(bucket)
+	if err := s.removeBucketTags(ctx, bucket); err != nil && !IsNotFound(err) {
+		return fmt.Errorf("removing tags of bucket %s: %w", bucket, err)
+	}
+	if len(tags) == 0 {
+		return nil
+	}
 
-	tagSet := `{"tagSets":[ {"tags":[`
-	for i := 0; i < len(tags); i++ {
-		obj := tags[i].(map[string]interface{})
-		tagSet = tagSet + fmt.Sprintf(`{"key":"%s","value":"%s"}`, obj["name"], obj["value"])
-		if i+1 < len(tags) {
-			tagSet = tagSet + ","
-		}
+	payload, err := s.protocol.marshal(Tagging{TagSets: []TagSet{{Tags: tags}}})
+	if err != nil {
+		return err
 	}
-	tagSet = tagSet + `]}]}`
 
-	_, err := s.doRequest(http.MethodPut, tagsUrl, tagSet, nil)
+	_, err = s.doRequest(ctx, http.MethodPut, tagsUrl, string(payload), nil)
 
 	return err
 }
 
-func (s S3Client) removeBucketTags(bucket string) error {
+// GetBucketTags returns the tags of the bucket. A bucket without tags has an
+// empty list.
+func (s S3Client) GetBucketTags(ctx context.Context, bucket string) ([]Tag, error) {
+	resp, err := s.doRequest(ctx, http.MethodGet, s.mountUrl(bucket, "tagging"), "", nil)
+	if IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var tagging Tagging
+	if err := s.protocol.unmarshal([]byte(resp), &tagging); err != nil {
+		return nil, fmt.Errorf("unmarshalling tags of bucket %s: %w", bucket, err)
+	}
+
+	var tags []Tag
+	for _, tagSet := range tagging.TagSets {
+		tags = append(tags, tagSet.Tags...)
+	}
+
+	return tags, nil
+}
+
+func (s S3Client) removeBucketTags(ctx context.Context, bucket string) error {
 	tagsUrl := s.mountUrl(bucket, "tagging")
 
-	_, err := s.doRequest(http.MethodDelete, tagsUrl, "", nil)
+	_, err := s.doRequest(ctx, http.MethodDelete, tagsUrl, "", nil)
 	return err
 }
 
-func (s S3Client) UploadObject(bucket, key, source string, overwrite bool) error {
-	objectUrl := s.mountUrl(bucket+"/"+key, fmt.Sprintf("overwrite=%t", overwrite))
-	return s.doUpload(objectUrl, source)
+// UploadObject uploads source to bucket/key. Files larger than the multipart
+// threshold are uploaded in parts.
+func (s S3Client) UploadObject(ctx context.Context, bucket, key, source string, overwrite bool) error {
+	file, err := os.Open(source)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	contentType, err := detectContentType(file)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[DEBUG] Uploading %s to %s/%s as %s", source, bucket, key, contentType)
+
+	if s.multipart.Threshold > 0 && info.Size() >= s.multipart.Threshold {
+		return s.uploadParts(ctx, bucket, key, overwrite, file, info.Size(), contentType)
+	}
+
+	objectUrl := s.mountUrl(objectResource(bucket, key), fmt.Sprintf("overwrite=%t", overwrite))
+	return s.doUpload(ctx, objectUrl, file, info.Size(), contentType)
 }
 
-func (s S3Client) BucketAcls(bucket string, setDefault bool, cannedAcl string, aclsI []interface{}) error {
+// HeadObject returns the metadata of bucket/key without downloading it.
+func (s S3Client) HeadObject(ctx context.Context, bucket, key string) (*ObjectInfo, error) {
+	objectUrl := s.mountUrl(objectResource(bucket, key), "")
+
+	resp, err := s.send(ctx, func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodHead, objectUrl, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	info := &ObjectInfo{
+		ETag:                 strings.Trim(resp.Header.Get("ETag"), `"`),
+		Size:                 resp.ContentLength,
+		ContentType:          resp.Header.Get("Content-Type"),
+		ServerSideEncryption: resp.Header.Get("X-Amz-Server-Side-Encryption"),
+	}
+	if lastModified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		info.LastModified = lastModified
+	}
+
+	return info, nil
+}
+
+// DeleteObject removes bucket/key. On a versioned bucket a plain delete only
+// adds a delete marker; with allVersions every version and delete marker of
+// the key is removed as well.
+func (s S3Client) DeleteObject(ctx context.Context, bucket, key string, allVersions bool) error {
+	objectUrl := s.mountUrl(objectResource(bucket, key), "")
+
+	if !allVersions {
+		_, err := s.doRequest(ctx, http.MethodDelete, objectUrl, "", nil)
+		return err
+	}
+
+	return s.listObjectVersions(ctx, bucket, key, func(objects []objectIdentity) error {
+		for _, o := range objects {
+			if o.Key != key {
+				continue
+			}
+			versionUrl := s.mountUrl(objectResource(bucket, key), "versionId="+url.QueryEscape(o.VersionId))
+			if _, err := s.doRequest(ctx, http.MethodDelete, versionUrl, "", nil); err != nil && !IsNotFound(err) {
+				return fmt.Errorf("deleting version %s of %s/%s: %w", o.VersionId, bucket, key, err)
+			}
+		}
+		return nil
+	})
+}
+
+// listObjectVersions calls fn with every page of object versions and delete
+// markers whose key starts with prefix.
+func (s S3Client) listObjectVersions(ctx context.Context, bucket, prefix string, fn func([]objectIdentity) error) error {
+	query := "versions"
+	if prefix != "" {
+		query += "&prefix=" + url.QueryEscape(prefix)
+	}
+
+	page := query
+	for {
+		listStr, err := s.doRequest(ctx, http.MethodGet, s.mountUrl(bucket, page), "", nil)
+		if err != nil {
+			return err
+		}
+
+		var list listVersionsResult
+		if err := s.protocol.unmarshal([]byte(listStr), &list); err != nil {
+			return fmt.Errorf("unmarshalling versions of bucket %s: %w", bucket, err)
+		}
+
+		if objects := append(list.Versions, list.DeleteMarkers...); len(objects) > 0 {
+			if err := fn(objects); err != nil {
+				return err
+			}
+		}
+
+		if !list.IsTruncated {
+			return nil
+		}
+		page = query + "&key-marker=" + url.QueryEscape(list.NextKeyMarker) + "&version-id-marker=" + url.QueryEscape(list.NextVersionIdMarker)
+	}
+}
+
+// waitBucketEmpty polls the bucket, backing off as the retry policy does,
+// until it holds no object version or delete marker. It gives up when ctx is
+// done, e.g. when the delete timeout of the resource expires.
+func (s S3Client) waitBucketEmpty(ctx context.Context, bucket string) error {
+	for attempt := 1; ; attempt++ {
+		listStr, err := s.doRequest(ctx, http.MethodGet, s.mountUrl(bucket, "versions&max-keys=1"), "", nil)
+		if err != nil {
+			return err
+		}
+
+		var list listVersionsResult
+		if err := s.protocol.unmarshal([]byte(listStr), &list); err != nil {
+			return fmt.Errorf("unmarshalling versions of bucket %s: %w", bucket, err)
+		}
+		if len(list.Versions) == 0 && len(list.DeleteMarkers) == 0 {
+			return nil
+		}
+
+		// The attempt is capped so the doubling of the backoff cannot overflow.
+		delay := s.retry.delay(min(attempt, 16), nil)
+		log.Printf("[DEBUG] Bucket %s is still being emptied, checking again in %s", bucket, delay)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for bucket %s to be emptied: %w", bucket, ctx.Err())
+		case <-time.After(delay):
+		}
+	}
+}
+
+// BucketAcls replaces the grants of the bucket with acls, the bucket owner
+// keeping FULL_CONTROL. cannedAcl, when set, is sent as the x-amz-acl header.
+func (s S3Client) BucketAcls(ctx context.Context, bucket, cannedAcl string, acls []BucketAcl) error {
 	aclsUrl := s.mountUrl(bucket, "acl")
 
-	bucketObj, err := s.getBucketObject(bucket)
+	bucketObj, err := s.getBucketObject(ctx, bucket)
 	if err != nil {
-		log.Panicf("ERROR getting bucker %v", err)
 		return err
 	}
 
@@ -192,152 +520,317 @@ func (s S3Client) BucketAcls(bucket string, setDefault bool, cannedAcl string, a
 		cannedAclHeader["X-Amz-Acl"] = cannedAcl
 	}
 
-	if setDefault {
-		return s.defaultAcl(bucket, bucketObj, cannedAclHeader)
+	grants := aclGrants(bucketObj, acls)
+
+	payloadStr, err := s.protocol.marshal(AccessControlPolicy{Owner: bucketObj.Owner, Grants: grants})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[TRACE] ACL of bucket %s: %s", bucket, payloadStr)
+
+	_, err1 := s.doRequest(ctx, http.MethodPut, aclsUrl, string(payloadStr), cannedAclHeader)
+
+	return err1
+}
+
+// GetBucketAcl returns the owner and the grants of the bucket.
+func (s S3Client) GetBucketAcl(ctx context.Context, bucket string) (*AccessControlPolicy, error) {
+	resp, err := s.doRequest(ctx, http.MethodGet, s.mountUrl(bucket, "acl"), "", nil)
+	if err != nil {
+		return nil, err
 	}
 
-	var grants []map[string]interface{}
+	var acl AccessControlPolicy
+	if err := s.protocol.unmarshal([]byte(resp), &acl); err != nil {
+		return nil, fmt.Errorf("unmarshalling ACL of bucket %s: %w", bucket, err)
+	}
 
-	log.Printf("ACL %v", aclsI...)
-	for _, a := range aclsI {
-		acl := a.(map[string]interface{})
+	return &acl, nil
+}
 
-		log.Printf("ACL USER: %s", acl["user"])
+// aclGrants converts the acls of a bucket into OSE grants. The bucket owner
+// always keeps FULL_CONTROL.
+func aclGrants(bucketObj *Bucket, acls []BucketAcl) []Grant {
+	var grants []Grant
 
-		grantee := map[string]interface{}{}
-		grant := map[string]interface{}{}
-		switch acl["user"] {
+	for _, acl := range acls {
+		var grantee Grantee
+		switch acl.User {
 		case "TENANT":
-			grantee["id"] = bucketObj.Tenant + "|"
+			grantee = userGrantee(bucketObj.Tenant + "|")
 		case "AUTHENTICATED":
-			grantee["uri"] = "http://acs.amazonaws.com/groups/global/AuthenticatedUsers"
+			grantee = groupGrantee("http://acs.amazonaws.com/groups/global/AuthenticatedUsers")
 		case "PUBLIC":
-			grantee["uri"] = "http://acs.amazonaws.com/groups/global/AllUsers"
+			grantee = groupGrantee("http://acs.amazonaws.com/groups/global/AllUsers")
 		case "SYSTEM-LOGGER":
-			grantee["uri"] = "http://acs.amazonaws.com/groups/s3/LogDelivery"
+			grantee = groupGrantee("http://acs.amazonaws.com/groups/s3/LogDelivery")
 		}
-		grant["grantee"] = grantee
-		grant["permission"] = acl["permission"]
 
-		grants = append(grants, grant)
+		grants = append(grants, Grant{Grantee: grantee, Permission: acl.Permission})
 	}
 
-	grants = append(grants, map[string]interface{}{"grantee": map[string]interface{}{"id": bucketObj.Owner.Id}, "permission": "FULL_CONTROL"})
+	return append(grants, Grant{Grantee: userGrantee(bucketObj.Owner.Id), Permission: "FULL_CONTROL"})
+}
 
-	log.Printf("GRANTS %v", grants)
+// GrantUser returns the acl user of grant, the reverse of aclGrants. The
+// FULL_CONTROL grant of the bucket owner and grantees that have no acl user
+// are reported as not ok.
+func GrantUser(bucketObj *Bucket, grant Grant) (string, bool) {
+	switch {
+	case grant.Grantee.Id != "" && grant.Grantee.Id == bucketObj.Owner.Id:
+		return "", false
+	case grant.Grantee.Id != "" && grant.Grantee.Id == bucketObj.Tenant+"|":
+		return "TENANT", true
+	case grant.Grantee.Uri == "http://acs.amazonaws.com/groups/global/AuthenticatedUsers":
+		return "AUTHENTICATED", true
+	case grant.Grantee.Uri == "http://acs.amazonaws.com/groups/global/AllUsers":
+		return "PUBLIC", true
+	case grant.Grantee.Uri == "http://acs.amazonaws.com/groups/s3/LogDelivery":
+		return "SYSTEM-LOGGER", true
+	}
+	return "", false
+}
 
-	payload := map[string]interface{}{}
-	payload["owner"] = bucketObj.Owner
-	payload["grants"] = grants
+// BucketCors replaces the CORS rules of the bucket. Without rules the CORS
+// configuration is removed.
+func (s S3Client) BucketCors(ctx context.Context, bucket string, rules []CORSRule) error {
+	corsUrl := s.mountUrl(bucket, "cors")
 
-	log.Printf("payload %v", payload)
+	if len(rules) == 0 {
+		_, err := s.doRequest(ctx, http.MethodDelete, corsUrl, "", nil)
+		if IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
 
-	payloadStr, err := json.Marshal(payload)
+	payloadStr, err := s.protocol.marshal(CORSConfiguration{Rules: rules})
 	if err != nil {
 		return err
 	}
 
-	log.Println("payload STR ==> " + string(payloadStr))
+	log.Printf("[TRACE] CORS configuration of bucket %s: %s", bucket, payloadStr)
 
-	_, err1 := s.doRequest(http.MethodPut, aclsUrl, string(payloadStr), cannedAclHeader)
+	_, err1 := s.doRequest(ctx, http.MethodPut, corsUrl, string(payloadStr), nil)
 
 	return err1
 }
 
-func toCamelCase(s string) string {
-	re, _ := regexp.Compile(`[-_]\w`)
-	res := re.ReplaceAllStringFunc(s, func(m string) string {
-		return strings.ToUpper(m[1:])
-	})
-	return res
-}
+// GetBucketCors returns the CORS rules of the bucket. A bucket without CORS
+// configuration has no rules.
+func (s S3Client) GetBucketCors(ctx context.Context, bucket string) ([]CORSRule, error) {
+	resp, err := s.doRequest(ctx, http.MethodGet, s.mountUrl(bucket, "cors"), "", nil)
+	if IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
 
-func (s S3Client) BucketCors(bucket string, corsI []interface{}) error {
-	corsUrl := s.mountUrl(bucket, "cors")
+	var cors CORSConfiguration
+	if err := s.protocol.unmarshal([]byte(resp), &cors); err != nil {
+		return nil, fmt.Errorf("unmarshalling CORS of bucket %s: %w", bucket, err)
+	}
 
-	cors := map[string]interface{}{}
+	return cors.Rules, nil
+}
 
-	payload := map[string][]interface{}{}
+// BucketLifecycle replaces the lifecycle rules of the bucket. A configuration
+// without rules removes the lifecycle configuration.
+func (s S3Client) BucketLifecycle(ctx context.Context, bucket string, lifecycle LifecycleConfiguration) error {
+	lifecycleUrl := s.mountUrl(bucket, "lifecycle")
 
-	for _, c := range corsI {
-		for k, v := range c.(map[string]interface{}) {
-			cors[toCamelCase(k)] = v
+	if len(lifecycle.Rules) == 0 {
+		_, err := s.doRequest(ctx, http.MethodDelete, lifecycleUrl, "", nil)
+		if IsNotFound(err) {
+			return nil
 		}
-		payload["corsRules"] = append(payload["corsRules"], cors)
+		return err
 	}
 
-	// cors["id"] = uuid.New()
-
-	log.Printf("payload %v", payload)
-
-	payloadStr, err := json.Marshal(payload)
+	payload, err := s.protocol.marshal(lifecycle)
 	if err != nil {
 		return err
 	}
 
-	log.Println("payload STR ==> " + string(payloadStr))
+	_, err = s.doRequest(ctx, http.MethodPut, lifecycleUrl, string(payload), nil)
+	return err
+}
 
-	_, err1 := s.doRequest(http.MethodPut, corsUrl, string(payloadStr), nil)
+// GetBucketLifecycle returns the lifecycle rules of the bucket. A bucket
+// without lifecycle configuration has no rules.
+func (s S3Client) GetBucketLifecycle(ctx context.Context, bucket string) ([]LifecycleRule, error) {
+	resp, err := s.doRequest(ctx, http.MethodGet, s.mountUrl(bucket, "lifecycle"), "", nil)
+	if IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
 
-	return err1
+	var lifecycle LifecycleConfiguration
+	if err := s.protocol.unmarshal([]byte(resp), &lifecycle); err != nil {
+		return nil, fmt.Errorf("unmarshalling lifecycle of bucket %s: %w", bucket, err)
+	}
+
+	return lifecycle.Rules, nil
 }
 
-func (s S3Client) defaultAcl(bucketName string, bucket *Bucket, cannedAclHeader map[string]string) error {
-	aclsUrl := s.mountUrl(bucketName, "acl")
+// BucketPolicy replaces the policy of the bucket with policy, an IAM policy
+// document in JSON whatever the protocol. An empty policy removes it.
+func (s S3Client) BucketPolicy(ctx context.Context, bucket, policy string) error {
+	policyUrl := s.mountUrl(bucket, "policy")
 
-	var grants []map[string]interface{}
+	if policy == "" {
+		_, err := s.doRequest(ctx, http.MethodDelete, policyUrl, "", nil)
+		if IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
 
-	grants = append(grants, map[string]interface{}{"grantee": map[string]interface{}{"id": bucket.Owner.Id}, "permission": "FULL_CONTROL"})
+	_, err := s.doRequest(ctx, http.MethodPut, policyUrl, policy, nil)
+	return err
+}
 
-	payload := map[string]interface{}{}
-	payload["owner"] = bucket.Owner
-	payload["grants"] = grants
+// GetBucketPolicy returns the policy document of the bucket, empty when the
+// bucket has no policy.
+func (s S3Client) GetBucketPolicy(ctx context.Context, bucket string) (string, error) {
+	policy, err := s.doRequest(ctx, http.MethodGet, s.mountUrl(bucket, "policy"), "", nil)
+	if IsNotFound(err) {
+		return "", nil
+	}
+	return policy, err
+}
 
-	payloadStr, err := json.Marshal(payload)
+// BucketEncryption replaces the default encryption of the bucket. A
+// configuration without rules removes it.
+func (s S3Client) BucketEncryption(ctx context.Context, bucket string, encryption ServerSideEncryptionConfiguration) error {
+	encryptionUrl := s.mountUrl(bucket, "encryption")
+
+	if len(encryption.Rules) == 0 {
+		_, err := s.doRequest(ctx, http.MethodDelete, encryptionUrl, "", nil)
+		if IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	payload, err := s.protocol.marshal(encryption)
 	if err != nil {
 		return err
 	}
 
-	log.Println(string(payloadStr))
+	_, err = s.doRequest(ctx, http.MethodPut, encryptionUrl, string(payload), nil)
+	return err
+}
 
-	_, err1 := s.doRequest(http.MethodPut, aclsUrl, string(payloadStr), cannedAclHeader)
+// GetBucketEncryption returns the default encryption rules of the bucket. A
+// bucket without default encryption has no rules.
+func (s S3Client) GetBucketEncryption(ctx context.Context, bucket string) ([]ServerSideEncryptionRule, error) {
+	resp, err := s.doRequest(ctx, http.MethodGet, s.mountUrl(bucket, "encryption"), "", nil)
+	if IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
 
-	return err1
+	var encryption ServerSideEncryptionConfiguration
+	if err := s.protocol.unmarshal([]byte(resp), &encryption); err != nil {
+		return nil, fmt.Errorf("unmarshalling encryption of bucket %s: %w", bucket, err)
+	}
+
+	return encryption.Rules, nil
 }
 
-func (s S3Client) getBucketObject(name string) (*Bucket, error) {
-	bucketStr, err := s.GetBucket(name)
+// BucketLogging enables server access logging of the bucket, or disables it
+// when logging.LoggingEnabled is nil.
+func (s S3Client) BucketLogging(ctx context.Context, bucket string, logging BucketLoggingStatus) error {
+	payload, err := s.protocol.marshal(logging)
 	if err != nil {
-		log.Panicf("ERROR getting bucker %v", err)
-		return nil, err
+		return err
 	}
 
-	var bucketObj *Bucket
-	if err := json.Unmarshal([]byte(bucketStr), &bucketObj); err != nil {
-		log.Println(bucketStr)
-		log.Panicf("ERROR unmarshalling bucket %v", err)
+	_, err = s.doRequest(ctx, http.MethodPut, s.mountUrl(bucket, "logging"), string(payload), nil)
+	return err
+}
+
+// GetBucketLogging returns the server access logging of the bucket.
+// LoggingEnabled is nil when logging is disabled.
+func (s S3Client) GetBucketLogging(ctx context.Context, bucket string) (*BucketLoggingStatus, error) {
+	resp, err := s.doRequest(ctx, http.MethodGet, s.mountUrl(bucket, "logging"), "", nil)
+	if err != nil {
 		return nil, err
 	}
 
-	return bucketObj, nil
+	var logging BucketLoggingStatus
+	if strings.TrimSpace(resp) == "" {
+		return &logging, nil
+	}
+	if err := s.protocol.unmarshal([]byte(resp), &logging); err != nil {
+		return nil, fmt.Errorf("unmarshalling logging of bucket %s: %w", bucket, err)
+	}
+
+	return &logging, nil
 }
 
-func (s S3Client) DeleteBucket(name string) error {
-	deleteUrl := s.mountUrl(name, "")
+// EnsureLogDeliveryGrants grants the LogDelivery group the WRITE and READ_ACP
+// permissions on the bucket, so it can be the target of access logs. The
+// other grants of the bucket are kept.
+func (s S3Client) EnsureLogDeliveryGrants(ctx context.Context, bucket string) error {
+	acl, err := s.GetBucketAcl(ctx, bucket)
+	if err != nil {
+		return err
+	}
 
-	payload := `{
-		"quiet": true,
-		"removeAll": true,
-		"deleteVersion": true,
-		"tryAsync": true
-	}`
+	grants, changed := withLogDeliveryGrants(acl.Grants)
+	if !changed {
+		return nil
+	}
 
-	_, err := s.doRequest(http.MethodPost, s.mountUrl(name, "delete"), payload, nil)
+	payload, err := s.protocol.marshal(AccessControlPolicy{Owner: acl.Owner, Grants: grants})
 	if err != nil {
-		log.Panicf("ERROR deleting all Objetcts of bucket %s: %v", name, err)
 		return err
 	}
 
-	_, err2 := s.doRequest(http.MethodDelete, deleteUrl, "", nil)
+	_, err = s.doRequest(ctx, http.MethodPut, s.mountUrl(bucket, "acl"), string(payload), nil)
+	return err
+}
+
+// withLogDeliveryGrants adds the grants of the LogDelivery group missing from
+// grants and reports whether any was.
+func withLogDeliveryGrants(grants []Grant) ([]Grant, bool) {
+	changed := false
+	for _, permission := range []string{"WRITE", "READ_ACP"} {
+		grant := Grant{Grantee: groupGrantee("http://acs.amazonaws.com/groups/s3/LogDelivery"), Permission: permission}
+		if !slices.ContainsFunc(grants, func(g Grant) bool {
+			return g.Grantee.Uri == grant.Grantee.Uri && g.Permission == grant.Permission
+		}) {
+			grants = append(grants, grant)
+			changed = true
+		}
+	}
+	return grants, changed
+}
+
+func (s S3Client) getBucketObject(ctx context.Context, name string) (*Bucket, error) {
+	bucketObj, err := s.protocol.getBucket(ctx, s, name)
+	if err != nil {
+		return nil, fmt.Errorf("getting bucket %s: %w", name, err)
+	}
+
+	return bucketObj, nil
+}
+
+func (s S3Client) DeleteBucket(ctx context.Context, name string) error {
+	deleteUrl := s.mountUrl(name, "")
+
+	if err := s.protocol.emptyBucket(ctx, s, name); err != nil {
+		return fmt.Errorf("deleting all objects of bucket %s: %w", name, err)
+	}
+
+	_, err2 := s.doRequest(ctx, http.MethodDelete, deleteUrl, "", nil)
 	return err2
 }
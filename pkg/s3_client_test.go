@@ -0,0 +1,687 @@
+package pkg_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/josajunior81/terraform-provider-vcd-object-storage-ext/pkg"
+	"github.com/josajunior81/terraform-provider-vcd-object-storage-ext/pkg/osetest"
+)
+
+func newTestClient(t *testing.T, opts ...pkg.S3ClientOption) (*osetest.Server, pkg.S3Client) {
+	t.Helper()
+
+	server := osetest.NewServer()
+	t.Cleanup(server.Close)
+
+	policy := pkg.DefaultRetryPolicy()
+	policy.BaseDelay = 10 * time.Millisecond
+	policy.MaxDelay = 50 * time.Millisecond
+
+	opts = append([]pkg.S3ClientOption{pkg.WithRetryPolicy(policy)}, opts...)
+	return server, pkg.NewS3ClientWithKeys(server.URL, "us-east-1", "access", "secret", "", opts...)
+}
+
+func writeFile(t *testing.T, content []byte) string {
+	t.Helper()
+
+	source := filepath.Join(t.TempDir(), "object")
+	if err := os.WriteFile(source, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return source
+}
+
+func TestBucket(t *testing.T) {
+	ctx := context.Background()
+	_, client := newTestClient(t)
+
+	if err := client.CreateBucket(ctx, "b1"); err != nil {
+		t.Fatalf("CreateBucket: %v", err)
+	}
+	if err := client.CreateBucket(ctx, "b1"); !pkg.IsConflict(err) {
+		t.Fatalf("CreateBucket of an existing bucket: got %v, want a conflict", err)
+	}
+
+	bucketStr, err := client.GetBucket(ctx, "b1")
+	if err != nil {
+		t.Fatalf("GetBucket: %v", err)
+	}
+	var bucket pkg.Bucket
+	if err := json.Unmarshal([]byte(bucketStr), &bucket); err != nil {
+		t.Fatal(err)
+	}
+	if bucket.Name != "b1" || bucket.Tenant != osetest.Tenant || bucket.Owner.Id != osetest.OwnerId {
+		t.Errorf("GetBucket = %+v", bucket)
+	}
+
+	names, err := client.ListBuckets(ctx)
+	if err != nil {
+		t.Fatalf("ListBuckets: %v", err)
+	}
+	if !slices.Equal(names, []string{"b1"}) {
+		t.Errorf("ListBuckets = %v, want [b1]", names)
+	}
+
+	if _, err := client.GetBucket(ctx, "missing"); !pkg.IsNotFound(err) {
+		t.Errorf("GetBucket of a missing bucket: got %v, want not found", err)
+	}
+}
+
+func TestBucketTags(t *testing.T) {
+	ctx := context.Background()
+	_, client := newTestClient(t)
+
+	if err := client.CreateBucket(ctx, "b1"); err != nil {
+		t.Fatal(err)
+	}
+
+	tags, err := client.GetBucketTags(ctx, "b1")
+	if err != nil || len(tags) != 0 {
+		t.Fatalf("GetBucketTags of an untagged bucket = %v, %v", tags, err)
+	}
+
+	err = client.BucketTags(ctx, "b1", []pkg.Tag{
+		{Key: "env", Value: "test"},
+		{Key: "team", Value: "storage"},
+	})
+	if err != nil {
+		t.Fatalf("BucketTags: %v", err)
+	}
+
+	tags, err = client.GetBucketTags(ctx, "b1")
+	if err != nil {
+		t.Fatalf("GetBucketTags: %v", err)
+	}
+	want := []pkg.Tag{{Key: "env", Value: "test"}, {Key: "team", Value: "storage"}}
+	if !slices.Equal(tags, want) {
+		t.Errorf("GetBucketTags = %v, want %v", tags, want)
+	}
+
+	if err := client.BucketTags(ctx, "b1", nil); err != nil {
+		t.Fatalf("BucketTags removing every tag: %v", err)
+	}
+	if tags, _ := client.GetBucketTags(ctx, "b1"); len(tags) != 0 {
+		t.Errorf("GetBucketTags after removal = %v", tags)
+	}
+}
+
+func TestBucketAcl(t *testing.T) {
+	ctx := context.Background()
+	_, client := newTestClient(t)
+
+	if err := client.CreateBucket(ctx, "b1"); err != nil {
+		t.Fatal(err)
+	}
+	bucket := &pkg.Bucket{Tenant: osetest.Tenant, Owner: pkg.Owner{Id: osetest.OwnerId}}
+
+	err := client.BucketAcls(ctx, "b1", "", []pkg.BucketAcl{
+		{User: "PUBLIC", Permission: "READ"},
+		{User: "TENANT", Permission: "WRITE"},
+	})
+	if err != nil {
+		t.Fatalf("BucketAcls: %v", err)
+	}
+
+	acl, err := client.GetBucketAcl(ctx, "b1")
+	if err != nil {
+		t.Fatalf("GetBucketAcl: %v", err)
+	}
+	var users []string
+	for _, grant := range acl.Grants {
+		if user, ok := pkg.GrantUser(bucket, grant); ok {
+			users = append(users, user+":"+grant.Permission)
+		}
+	}
+	if want := []string{"PUBLIC:READ", "TENANT:WRITE"}; !slices.Equal(users, want) {
+		t.Errorf("grants = %v, want %v", users, want)
+	}
+
+	if err := client.BucketAcls(ctx, "b1", "public-read", nil); err != nil {
+		t.Fatalf("BucketAcls with a canned ACL: %v", err)
+	}
+	acl, err = client.GetBucketAcl(ctx, "b1")
+	if err != nil {
+		t.Fatalf("GetBucketAcl: %v", err)
+	}
+	if len(acl.Grants) != 2 || acl.Grants[1].Grantee.Uri != "http://acs.amazonaws.com/groups/global/AllUsers" {
+		t.Errorf("grants of public-read = %+v", acl.Grants)
+	}
+}
+
+func TestBucketCors(t *testing.T) {
+	ctx := context.Background()
+	_, client := newTestClient(t)
+
+	if err := client.CreateBucket(ctx, "b1"); err != nil {
+		t.Fatal(err)
+	}
+
+	rules, err := client.GetBucketCors(ctx, "b1")
+	if err != nil || len(rules) != 0 {
+		t.Fatalf("GetBucketCors without configuration = %v, %v", rules, err)
+	}
+
+	err = client.BucketCors(ctx, "b1", []pkg.CORSRule{{
+		AllowedMethods: []string{"GET", "PUT"},
+		AllowedOrigins: []string{"https://*.example.com"},
+		MaxAgeSeconds:  600,
+	}})
+	if err != nil {
+		t.Fatalf("BucketCors: %v", err)
+	}
+
+	rules, err = client.GetBucketCors(ctx, "b1")
+	if err != nil {
+		t.Fatalf("GetBucketCors: %v", err)
+	}
+	if len(rules) != 1 || !slices.Equal(rules[0].AllowedMethods, []string{"GET", "PUT"}) || rules[0].MaxAgeSeconds != 600 {
+		t.Errorf("GetBucketCors = %+v", rules)
+	}
+
+	if err := client.BucketCors(ctx, "b1", nil); err != nil {
+		t.Fatalf("BucketCors removing every rule: %v", err)
+	}
+	if err := client.BucketCors(ctx, "b1", nil); err != nil {
+		t.Fatalf("BucketCors without configuration: %v", err)
+	}
+}
+
+func TestBucketLifecycle(t *testing.T) {
+	ctx := context.Background()
+	_, client := newTestClient(t)
+
+	if err := client.CreateBucket(ctx, "b1"); err != nil {
+		t.Fatal(err)
+	}
+
+	rules, err := client.GetBucketLifecycle(ctx, "b1")
+	if err != nil || len(rules) != 0 {
+		t.Fatalf("GetBucketLifecycle without configuration = %v, %v", rules, err)
+	}
+
+	tags := []pkg.Tag{{Key: "kind", Value: "backup"}}
+	err = client.BucketLifecycle(ctx, "b1", pkg.LifecycleConfiguration{Rules: []pkg.LifecycleRule{
+		{
+			Id:         "backups",
+			Status:     pkg.LifecycleEnabled,
+			Filter:     pkg.NewLifecycleFilter("backups/", tags),
+			Expiration: &pkg.LifecycleExpiration{Days: 30},
+		},
+		{
+			Id:                             "uploads",
+			Status:                         pkg.LifecycleEnabled,
+			Filter:                         pkg.NewLifecycleFilter("", nil),
+			NoncurrentVersionExpiration:    &pkg.NoncurrentVersionExpiration{NoncurrentDays: 7},
+			AbortIncompleteMultipartUpload: &pkg.AbortIncompleteMultipartUpload{DaysAfterInitiation: 1},
+		},
+	}})
+	if err != nil {
+		t.Fatalf("BucketLifecycle: %v", err)
+	}
+
+	rules, err = client.GetBucketLifecycle(ctx, "b1")
+	if err != nil {
+		t.Fatalf("GetBucketLifecycle: %v", err)
+	}
+	if len(rules) != 2 || rules[0].Expiration.Days != 30 || rules[1].AbortIncompleteMultipartUpload.DaysAfterInitiation != 1 {
+		t.Fatalf("GetBucketLifecycle = %+v", rules)
+	}
+	if prefix, got := rules[0].Filter.PrefixAndTags(); prefix != "backups/" || !slices.Equal(got, tags) {
+		t.Errorf("filter of rule backups = %q, %v", prefix, got)
+	}
+
+	err = client.BucketLifecycle(ctx, "b1", pkg.LifecycleConfiguration{Rules: []pkg.LifecycleRule{
+		{Id: "noop", Status: pkg.LifecycleEnabled, Filter: pkg.NewLifecycleFilter("", nil)},
+	}})
+	var apiErr *pkg.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("BucketLifecycle of a rule without action = %v, want a bad request", err)
+	}
+
+	if err := client.BucketLifecycle(ctx, "b1", pkg.LifecycleConfiguration{}); err != nil {
+		t.Fatalf("BucketLifecycle removing every rule: %v", err)
+	}
+	if rules, err := client.GetBucketLifecycle(ctx, "b1"); err != nil || len(rules) != 0 {
+		t.Errorf("GetBucketLifecycle after removal = %v, %v", rules, err)
+	}
+}
+
+func TestBucketPolicy(t *testing.T) {
+	ctx := context.Background()
+	_, client := newTestClient(t)
+
+	if err := client.CreateBucket(ctx, "b1"); err != nil {
+		t.Fatal(err)
+	}
+
+	policy, err := client.GetBucketPolicy(ctx, "b1")
+	if err != nil || policy != "" {
+		t.Fatalf("GetBucketPolicy without policy = %q, %v", policy, err)
+	}
+
+	document := `{
+  "Version": "2012-10-17",
+  "Statement": [{"Effect": "Allow", "Principal": "*", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::b1/*"}]
+}`
+	if err := client.BucketPolicy(ctx, "b1", document); err != nil {
+		t.Fatalf("BucketPolicy: %v", err)
+	}
+
+	policy, err = client.GetBucketPolicy(ctx, "b1")
+	if err != nil {
+		t.Fatalf("GetBucketPolicy: %v", err)
+	}
+	var got, want interface{}
+	if err := json.Unmarshal([]byte(policy), &got); err != nil {
+		t.Fatalf("GetBucketPolicy = %q: %v", policy, err)
+	}
+	json.Unmarshal([]byte(document), &want)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetBucketPolicy = %s, want %s", policy, document)
+	}
+
+	if err := client.BucketPolicy(ctx, "b1", `{"Version": "2012-10-17"}`); err == nil {
+		t.Error("BucketPolicy accepted a policy without statement")
+	}
+
+	if err := client.BucketPolicy(ctx, "b1", ""); err != nil {
+		t.Fatalf("BucketPolicy removing the policy: %v", err)
+	}
+	if err := client.BucketPolicy(ctx, "b1", ""); err != nil {
+		t.Fatalf("BucketPolicy without policy: %v", err)
+	}
+}
+
+func TestBucketEncryption(t *testing.T) {
+	ctx := context.Background()
+	_, client := newTestClient(t)
+
+	if err := client.CreateBucket(ctx, "b1"); err != nil {
+		t.Fatal(err)
+	}
+
+	rules, err := client.GetBucketEncryption(ctx, "b1")
+	if err != nil || len(rules) != 0 {
+		t.Fatalf("GetBucketEncryption without configuration = %v, %v", rules, err)
+	}
+
+	byDefault := pkg.ServerSideEncryptionByDefault{SSEAlgorithm: pkg.SSEAlgorithmKMS, KMSMasterKeyID: "key-1"}
+	err = client.BucketEncryption(ctx, "b1", pkg.ServerSideEncryptionConfiguration{Rules: []pkg.ServerSideEncryptionRule{
+		{ApplyServerSideEncryptionByDefault: byDefault},
+	}})
+	if err != nil {
+		t.Fatalf("BucketEncryption: %v", err)
+	}
+
+	rules, err = client.GetBucketEncryption(ctx, "b1")
+	if err != nil || len(rules) != 1 || rules[0].ApplyServerSideEncryptionByDefault != byDefault {
+		t.Fatalf("GetBucketEncryption = %+v, %v", rules, err)
+	}
+
+	if err := client.BucketEncryption(ctx, "b1", pkg.ServerSideEncryptionConfiguration{}); err != nil {
+		t.Fatalf("BucketEncryption removing the configuration: %v", err)
+	}
+	if rules, err := client.GetBucketEncryption(ctx, "b1"); err != nil || len(rules) != 0 {
+		t.Errorf("GetBucketEncryption after removal = %v, %v", rules, err)
+	}
+}
+
+func TestUploadObjectEncrypted(t *testing.T) {
+	ctx := context.Background()
+	_, client := newTestClient(t)
+
+	content := []byte("secret")
+	sum := md5.Sum(content)
+	source := writeFile(t, content)
+
+	for _, algorithm := range []string{pkg.SSEAlgorithmAES256, pkg.SSEAlgorithmKMS} {
+		if err := client.CreateBucket(ctx, algorithm); err != nil {
+			t.Fatal(err)
+		}
+		err := client.BucketEncryption(ctx, algorithm, pkg.ServerSideEncryptionConfiguration{Rules: []pkg.ServerSideEncryptionRule{
+			{ApplyServerSideEncryptionByDefault: pkg.ServerSideEncryptionByDefault{SSEAlgorithm: algorithm}},
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := client.UploadObject(ctx, algorithm, "secret.txt", source, true); err != nil {
+			t.Fatalf("UploadObject: %v", err)
+		}
+
+		info, err := client.HeadObject(ctx, algorithm, "secret.txt")
+		if err != nil {
+			t.Fatalf("HeadObject: %v", err)
+		}
+		if info.ServerSideEncryption != algorithm {
+			t.Errorf("HeadObject encryption = %q, want %q", info.ServerSideEncryption, algorithm)
+		}
+		if isMD5 := info.ETag == hex.EncodeToString(sum[:]); isMD5 != info.ETagIsMD5() {
+			t.Errorf("%s: ETagIsMD5() = %v, etag %s is the MD5 %v", algorithm, info.ETagIsMD5(), info.ETag, isMD5)
+		}
+	}
+}
+
+func TestObjectInfoETagIsMD5(t *testing.T) {
+	tests := []struct {
+		info pkg.ObjectInfo
+		want bool
+	}{
+		{pkg.ObjectInfo{ETag: "5d41402abc4b2a76b9719d911017c592"}, true},
+		{pkg.ObjectInfo{ETag: "5d41402abc4b2a76b9719d911017c592", ServerSideEncryption: pkg.SSEAlgorithmAES256}, true},
+		{pkg.ObjectInfo{ETag: "0c1a7b5f3a4e6d8c9b2a1f0e3d4c5b6a", ServerSideEncryption: pkg.SSEAlgorithmKMS}, false},
+		{pkg.ObjectInfo{ETag: "0c1a7b5f3a4e6d8c9b2a1f0e3d4c5b6a", ServerSideEncryption: "aws:kms:dsse"}, false},
+		{pkg.ObjectInfo{ETag: "3858f62230ac3c915f300c664312c11f-2"}, false},
+		{pkg.ObjectInfo{}, false},
+	}
+
+	for _, test := range tests {
+		if got := test.info.ETagIsMD5(); got != test.want {
+			t.Errorf("%+v.ETagIsMD5() = %v, want %v", test.info, got, test.want)
+		}
+	}
+}
+
+func TestBucketLogging(t *testing.T) {
+	ctx := context.Background()
+	_, client := newTestClient(t)
+
+	for _, name := range []string{"b1", "logs"} {
+		if err := client.CreateBucket(ctx, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := client.BucketAcls(ctx, "logs", "", []pkg.BucketAcl{
+		{User: "TENANT", Permission: "READ"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logging, err := client.GetBucketLogging(ctx, "b1")
+	if err != nil || logging.LoggingEnabled != nil {
+		t.Fatalf("GetBucketLogging without logging = %+v, %v", logging, err)
+	}
+
+	enabled := pkg.LoggingEnabled{TargetBucket: "logs", TargetPrefix: "b1/"}
+	err = client.BucketLogging(ctx, "b1", pkg.BucketLoggingStatus{LoggingEnabled: &enabled})
+	var apiErr *pkg.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("BucketLogging without the LogDelivery grants = %v, want a bad request", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := client.EnsureLogDeliveryGrants(ctx, "logs"); err != nil {
+			t.Fatalf("EnsureLogDeliveryGrants: %v", err)
+		}
+	}
+	acl, err := client.GetBucketAcl(ctx, "logs")
+	if err != nil {
+		t.Fatalf("GetBucketAcl: %v", err)
+	}
+	bucket := &pkg.Bucket{Tenant: osetest.Tenant, Owner: pkg.Owner{Id: osetest.OwnerId}}
+	var users []string
+	for _, grant := range acl.Grants {
+		if user, ok := pkg.GrantUser(bucket, grant); ok {
+			users = append(users, user+":"+grant.Permission)
+		}
+	}
+	if want := []string{"TENANT:READ", "SYSTEM-LOGGER:WRITE", "SYSTEM-LOGGER:READ_ACP"}; !slices.Equal(users, want) {
+		t.Errorf("grants = %v, want %v", users, want)
+	}
+
+	if err := client.BucketLogging(ctx, "b1", pkg.BucketLoggingStatus{LoggingEnabled: &enabled}); err != nil {
+		t.Fatalf("BucketLogging: %v", err)
+	}
+	logging, err = client.GetBucketLogging(ctx, "b1")
+	if err != nil || logging.LoggingEnabled == nil || *logging.LoggingEnabled != enabled {
+		t.Fatalf("GetBucketLogging = %+v, %v", logging, err)
+	}
+
+	if err := client.BucketLogging(ctx, "b1", pkg.BucketLoggingStatus{}); err != nil {
+		t.Fatalf("BucketLogging disabling logging: %v", err)
+	}
+	if logging, err := client.GetBucketLogging(ctx, "b1"); err != nil || logging.LoggingEnabled != nil {
+		t.Errorf("GetBucketLogging after disabling = %+v, %v", logging, err)
+	}
+}
+
+func TestUploadObject(t *testing.T) {
+	ctx := context.Background()
+	server, client := newTestClient(t)
+
+	if err := client.CreateBucket(ctx, "b1"); err != nil {
+		t.Fatal(err)
+	}
+
+	content := []byte("hello object storage")
+	source := writeFile(t, content)
+
+	if err := client.UploadObject(ctx, "b1", "dir/hello.txt", source, false); err != nil {
+		t.Fatalf("UploadObject: %v", err)
+	}
+	if err := client.UploadObject(ctx, "b1", "dir/hello.txt", source, false); !pkg.IsConflict(err) {
+		t.Errorf("UploadObject without overwrite: got %v, want a conflict", err)
+	}
+	if err := client.UploadObject(ctx, "b1", "dir/hello.txt", source, true); err != nil {
+		t.Errorf("UploadObject with overwrite: %v", err)
+	}
+
+	info, err := client.HeadObject(ctx, "b1", "dir/hello.txt")
+	if err != nil {
+		t.Fatalf("HeadObject: %v", err)
+	}
+	sum := md5.Sum(content)
+	if info.ETag != hex.EncodeToString(sum[:]) || info.Size != int64(len(content)) || info.ContentType != "text/plain; charset=utf-8" {
+		t.Errorf("HeadObject = %+v", info)
+	}
+	if info.LastModified.IsZero() {
+		t.Error("HeadObject returned no LastModified")
+	}
+	if data, _ := server.Object("b1", "dir/hello.txt"); !bytes.Equal(data, content) {
+		t.Errorf("stored content = %q, want %q", data, content)
+	}
+
+	if err := client.DeleteObject(ctx, "b1", "dir/hello.txt", false); err != nil {
+		t.Fatalf("DeleteObject: %v", err)
+	}
+	if _, err := client.HeadObject(ctx, "b1", "dir/hello.txt"); !pkg.IsNotFound(err) {
+		t.Errorf("HeadObject of a deleted object: got %v, want not found", err)
+	}
+}
+
+func TestUploadObjectReservedCharacters(t *testing.T) {
+	ctx := context.Background()
+	server, client := newTestClient(t)
+
+	if err := client.CreateBucket(ctx, "b1"); err != nil {
+		t.Fatal(err)
+	}
+
+	source := writeFile(t, []byte("reserved"))
+	for _, key := range []string{
+		"dir/a b(1)=+!*$,;:@&'.txt",
+		"?query#fragment",
+		"100%/ሴ.txt",
+	} {
+		if err := client.UploadObject(ctx, "b1", key, source, true); err != nil {
+			t.Fatalf("UploadObject(%q): %v", key, err)
+		}
+		if data, ok := server.Object("b1", key); !ok || string(data) != "reserved" {
+			t.Errorf("stored object %q = %q, %v", key, data, ok)
+		}
+		if _, err := client.HeadObject(ctx, "b1", key); err != nil {
+			t.Errorf("HeadObject(%q): %v", key, err)
+		}
+		if err := client.DeleteObject(ctx, "b1", key, false); err != nil {
+			t.Errorf("DeleteObject(%q): %v", key, err)
+		}
+		if _, ok := server.Object("b1", key); ok {
+			t.Errorf("object %q still exists after DeleteObject", key)
+		}
+	}
+}
+
+func TestUploadObjectMultipart(t *testing.T) {
+	ctx := context.Background()
+	server, client := newTestClient(t, pkg.WithMultipartConfig(pkg.MultipartConfig{
+		Threshold:   1,
+		PartSize:    5 << 20,
+		Concurrency: 2,
+	}))
+
+	if err := client.CreateBucket(ctx, "b1"); err != nil {
+		t.Fatal(err)
+	}
+
+	content := bytes.Repeat([]byte("0123456789abcdef"), (11<<20)/16)
+	source := writeFile(t, content)
+
+	if err := client.UploadObject(ctx, "b1", "large.bin", source, true); err != nil {
+		t.Fatalf("UploadObject: %v", err)
+	}
+
+	info, err := client.HeadObject(ctx, "b1", "large.bin")
+	if err != nil {
+		t.Fatalf("HeadObject: %v", err)
+	}
+	if info.Size != int64(len(content)) || info.ETag[len(info.ETag)-2:] != "-3" {
+		t.Errorf("HeadObject = %+v, want 3 parts of %d bytes", info, len(content))
+	}
+	if info.ContentType != "text/plain; charset=utf-8" {
+		t.Errorf("HeadObject content type = %q, want the detected one", info.ContentType)
+	}
+	if data, _ := server.Object("b1", "large.bin"); !bytes.Equal(data, content) {
+		t.Error("stored content differs from the source")
+	}
+	if n := server.Uploads(); n != 0 {
+		t.Errorf("%d multipart uploads left in progress", n)
+	}
+}
+
+func TestUploadObjectMultipartAborted(t *testing.T) {
+	ctx := context.Background()
+	server, client := newTestClient(t, pkg.WithMultipartConfig(pkg.MultipartConfig{
+		Threshold:   1,
+		PartSize:    5 << 20,
+		Concurrency: 1,
+	}))
+
+	if err := client.CreateBucket(ctx, "b1"); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var aborted []string
+	server.Intercept = func(w http.ResponseWriter, r *http.Request) bool {
+		query := r.URL.Query()
+		switch {
+		case r.Method == http.MethodPut && query.Get("partNumber") == "2":
+			w.WriteHeader(http.StatusBadRequest)
+			return true
+		case r.Method == http.MethodDelete && query.Has("uploadId"):
+			mu.Lock()
+			aborted = append(aborted, query.Get("uploadId"))
+			mu.Unlock()
+		}
+		return false
+	}
+
+	content := bytes.Repeat([]byte("0123456789abcdef"), (11<<20)/16)
+	if err := client.UploadObject(ctx, "b1", "large.bin", writeFile(t, content), true); err == nil {
+		t.Fatal("UploadObject succeeded with a failing part")
+	}
+
+	if len(aborted) != 1 || aborted[0] == "" {
+		t.Fatalf("aborted uploads = %q, want the failed upload", aborted)
+	}
+	if n := server.Uploads(); n != 0 {
+		t.Errorf("%d multipart uploads left in progress, want the failed one aborted", n)
+	}
+	if _, ok := server.Object("b1", "large.bin"); ok {
+		t.Error("the failed upload stored the object")
+	}
+}
+
+func TestDeleteObjectVersions(t *testing.T) {
+	ctx := context.Background()
+	server, client := newTestClient(t)
+	server.MaxKeys = 2
+
+	if err := client.CreateBucket(ctx, "b1"); err != nil {
+		t.Fatal(err)
+	}
+	server.EnableVersioning("b1")
+
+	for _, content := range []string{"v1", "v2", "v3"} {
+		if err := client.UploadObject(ctx, "b1", "key", writeFile(t, []byte(content)), true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := client.DeleteObject(ctx, "b1", "key", false); err != nil {
+		t.Fatalf("DeleteObject: %v", err)
+	}
+	if n := server.Versions("b1", "key"); n != 4 {
+		t.Fatalf("versions after a plain delete = %d, want 3 versions and a delete marker", n)
+	}
+
+	if err := client.DeleteObject(ctx, "b1", "key", true); err != nil {
+		t.Fatalf("DeleteObject of every version: %v", err)
+	}
+	if n := server.Versions("b1", "key"); n != 0 {
+		t.Errorf("versions after deleting every version = %d, want 0", n)
+	}
+}
+
+func TestDeleteBucket(t *testing.T) {
+	ctx := context.Background()
+	server, client := newTestClient(t)
+
+	if err := client.CreateBucket(ctx, "b1"); err != nil {
+		t.Fatal(err)
+	}
+	server.EnableVersioning("b1")
+	for _, key := range []string{"a", "b", "c"} {
+		if err := client.UploadObject(ctx, "b1", key, writeFile(t, []byte(key)), true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The emulator purges asynchronously, so DeleteBucket has to wait for
+	// the bucket to be empty before deleting it.
+	if err := client.DeleteBucket(ctx, "b1"); err != nil {
+		t.Fatalf("DeleteBucket: %v", err)
+	}
+	if buckets := server.Buckets(); len(buckets) != 0 {
+		t.Errorf("buckets after DeleteBucket = %v", buckets)
+	}
+	if err := client.DeleteBucket(ctx, "b1"); !pkg.IsNotFound(err) {
+		t.Errorf("DeleteBucket of a missing bucket: got %v, want not found", err)
+	}
+}
+
+func TestDeleteBucketTimeout(t *testing.T) {
+	_, client := newTestClient(t)
+
+	if err := client.CreateBucket(context.Background(), "b1"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := client.DeleteBucket(ctx, "b1"); err == nil {
+		t.Error("DeleteBucket succeeded with a cancelled context")
+	}
+}
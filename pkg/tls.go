@@ -0,0 +1,89 @@
+package pkg
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/vmware/go-vcloud-director/v2/govcd"
+)
+
+// tlsVersions maps the accepted minimum TLS versions to their crypto/tls values.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// TLSVersions returns the minimum TLS versions accepted by NewTLSConfig.
+func TLSVersions() []string {
+	return []string{"1.0", "1.1", "1.2", "1.3"}
+}
+
+// NewTLSConfig builds the TLS configuration shared by the VCD and S3 clients.
+// caFile and caPem are PEM encoded CA bundles added to the system roots;
+// either may be empty. minVersion defaults to TLS 1.2 when empty.
+func NewTLSConfig(insecure bool, caFile, caPem, minVersion string) (*tls.Config, error) {
+	config := &tls.Config{
+		InsecureSkipVerify: insecure,
+		MinVersion:         tls.VersionTLS12,
+	}
+
+	if minVersion != "" {
+		version, ok := tlsVersions[minVersion]
+		if !ok {
+			return nil, fmt.Errorf("unsupported minimum TLS version %q", minVersion)
+		}
+		config.MinVersion = version
+	}
+
+	if caFile == "" && caPem == "" {
+		return config, nil
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+
+	if caFile != "" {
+		bundle, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA bundle: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(bundle) {
+			return nil, fmt.Errorf("no PEM certificates found in CA bundle %s", caFile)
+		}
+	}
+
+	if caPem != "" && !pool.AppendCertsFromPEM([]byte(caPem)) {
+		return nil, fmt.Errorf("no PEM certificates found in CA certificate")
+	}
+
+	config.RootCAs = pool
+	return config, nil
+}
+
+// WithTLSConfig sets the TLS configuration used to reach both VCD and the
+// Object Storage Extension. By default certificates are verified against the
+// system roots.
+func WithTLSConfig(config *tls.Config) S3ClientOption {
+	return func(s *S3Client) {
+		s.tlsConfig = config
+	}
+}
+
+// vcdTLSConfig applies config to the HTTP transport of the VCD client.
+func vcdTLSConfig(config *tls.Config) govcd.VCDClientOption {
+	return func(vcdClient *govcd.VCDClient) error {
+		transport, ok := vcdClient.Client.Http.Transport.(*http.Transport)
+		if !ok {
+			return fmt.Errorf("unexpected VCD client transport %T", vcdClient.Client.Http.Transport)
+		}
+		transport.TLSClientConfig = config.Clone()
+		return nil
+	}
+}
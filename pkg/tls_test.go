@@ -0,0 +1,215 @@
+package pkg_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/josajunior81/terraform-provider-vcd-object-storage-ext/pkg"
+)
+
+// newTLSServer starts a server whose certificate is issued by a CA of its
+// own, negotiating at most maxVersion. It answers the VCD token exchange and
+// every S3 request with an empty document, and returns the PEM of its CA.
+func newTLSServer(t *testing.T, maxVersion uint16) (*httptest.Server, string) {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "osetest CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, ca, &leafKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/oauth/") {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"access_token":"token","token_type":"Bearer","expires_in":3600}`))
+			return
+		}
+		w.Write([]byte("{}"))
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{leafDER}, PrivateKey: leafKey}},
+		MaxVersion:   maxVersion,
+	}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	return server, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}))
+}
+
+// newTLSClient returns a client of server that gives up after one attempt,
+// since TLS errors are retried like any transport error.
+func newTLSClient(server *httptest.Server, config *tls.Config) pkg.S3Client {
+	policy := pkg.DefaultRetryPolicy()
+	policy.MaxAttempts = 1
+
+	return pkg.NewS3ClientWithKeys(server.URL, "us-east-1", "access", "secret", "", pkg.WithRetryPolicy(policy), pkg.WithTLSConfig(config))
+}
+
+func isVerificationError(err error) bool {
+	var verifyErr *tls.CertificateVerificationError
+	return errors.As(err, &verifyErr)
+}
+
+func TestTLSCABundle(t *testing.T) {
+	ctx := context.Background()
+	server, caPem := newTLSServer(t, tls.VersionTLS13)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, []byte(caPem), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		caFile, caPem string
+	}{
+		{"ca_file", caFile, ""},
+		{"ca_pem", "", caPem},
+	}
+	for _, test := range tests {
+		config, err := pkg.NewTLSConfig(false, test.caFile, test.caPem, "")
+		if err != nil {
+			t.Fatalf("%s: NewTLSConfig: %v", test.name, err)
+		}
+		if _, err := newTLSClient(server, config).GetBucketPolicy(ctx, "b1"); err != nil {
+			t.Errorf("%s: GetBucketPolicy: %v", test.name, err)
+		}
+	}
+
+	config, err := pkg.NewTLSConfig(false, "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newTLSClient(server, config).GetBucketPolicy(ctx, "b1"); !isVerificationError(err) {
+		t.Errorf("GetBucketPolicy without the CA = %v, want a certificate verification error", err)
+	}
+
+	config, err = pkg.NewTLSConfig(true, "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newTLSClient(server, config).GetBucketPolicy(ctx, "b1"); err != nil {
+		t.Errorf("GetBucketPolicy with insecure: %v", err)
+	}
+}
+
+func TestTLSCABundleVCD(t *testing.T) {
+	server, caPem := newTLSServer(t, tls.VersionTLS13)
+
+	config, err := pkg.NewTLSConfig(false, "", caPem, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pkg.NewS3Client(server.URL, "us-east-1", "token", "org", server.URL, pkg.WithTLSConfig(config)); err != nil {
+		t.Errorf("NewS3Client with the CA: %v", err)
+	}
+
+	config, err = pkg.NewTLSConfig(false, "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pkg.NewS3Client(server.URL, "us-east-1", "token", "org", server.URL, pkg.WithTLSConfig(config)); !errors.Is(err, pkg.ErrAuthentication) {
+		t.Errorf("NewS3Client without the CA = %v, want ErrAuthentication", err)
+	}
+}
+
+func TestTLSInvalidCABundle(t *testing.T) {
+	dir := t.TempDir()
+	garbage := filepath.Join(dir, "garbage.pem")
+	if err := os.WriteFile(garbage, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		caFile, caPem string
+		want          string
+	}{
+		{"missing file", filepath.Join(dir, "missing.pem"), "", "reading CA bundle"},
+		{"file without PEM", garbage, "", "no PEM certificates found in CA bundle"},
+		{"invalid PEM", "", "-----BEGIN CERTIFICATE-----\nnot base64\n-----END CERTIFICATE-----\n", "no PEM certificates found in CA certificate"},
+	}
+	for _, test := range tests {
+		_, err := pkg.NewTLSConfig(false, test.caFile, test.caPem, "")
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: NewTLSConfig = %v, want %q", test.name, err, test.want)
+		}
+	}
+}
+
+func TestTLSMinVersion(t *testing.T) {
+	ctx := context.Background()
+	server, caPem := newTLSServer(t, tls.VersionTLS12)
+
+	if _, err := pkg.NewTLSConfig(false, "", "", "1.4"); err == nil {
+		t.Error("NewTLSConfig accepted TLS 1.4")
+	}
+
+	config, err := pkg.NewTLSConfig(false, "", caPem, "1.2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newTLSClient(server, config).GetBucketPolicy(ctx, "b1"); err != nil {
+		t.Errorf("GetBucketPolicy with minimum TLS 1.2: %v", err)
+	}
+
+	config, err = pkg.NewTLSConfig(false, "", caPem, "1.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = newTLSClient(server, config).GetBucketPolicy(ctx, "b1")
+	if err == nil || !strings.Contains(err.Error(), "protocol version") {
+		t.Errorf("GetBucketPolicy with minimum TLS 1.3 against a TLS 1.2 server = %v, want a protocol version error", err)
+	}
+}
@@ -0,0 +1,81 @@
+package pkg
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vmware/go-vcloud-director/v2/types/v56"
+)
+
+// tokenRefreshMargin is how long before its expiry a bearer token is refreshed.
+const tokenRefreshMargin = 2 * time.Minute
+
+// bearerTokenSource exchanges the VCD API token for bearer tokens and
+// refreshes them before they lapse. It is shared by every copy of an S3Client,
+// so concurrent resource operations trigger a single refresh.
+type bearerTokenSource struct {
+	mu       sync.Mutex
+	exchange func() (*types.ApiTokenRefresh, error)
+	token    string
+	expiry   time.Time
+}
+
+func newBearerTokenSource(exchange func() (*types.ApiTokenRefresh, error)) *bearerTokenSource {
+	return &bearerTokenSource{exchange: exchange}
+}
+
+// Token returns a valid bearer token, refreshing it when it is missing or
+// about to expire.
+func (t *bearerTokenSource) Token() (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.token != "" && (t.expiry.IsZero() || time.Until(t.expiry) > tokenRefreshMargin) {
+		return t.token, nil
+	}
+
+	log.Printf("[DEBUG] Refreshing VCD bearer token")
+	refresh, err := t.exchange()
+	if err != nil {
+		return "", err
+	}
+
+	t.token = refresh.AccessToken
+	t.expiry = time.Time{}
+	if refresh.ExpiresIn > 0 {
+		t.expiry = time.Now().Add(time.Duration(refresh.ExpiresIn) * time.Second)
+	}
+
+	return t.token, nil
+}
+
+// invalidate drops stale so the next Token call re-authenticates. A token
+// already replaced by another goroutine is left alone.
+func (t *bearerTokenSource) invalidate(stale string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.token == stale {
+		t.token = ""
+	}
+}
+
+func (t *bearerTokenSource) authorize(req *http.Request) error {
+	token, err := t.Token()
+	if err != nil {
+		log.Printf("Error getting bearer token: %s", err)
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// refresh drops the bearer token req was sent with so the replay
+// re-authenticates.
+func (t *bearerTokenSource) refresh(req *http.Request) bool {
+	t.invalidate(strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "))
+	return true
+}
@@ -0,0 +1,199 @@
+package pkg_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/josajunior81/terraform-provider-vcd-object-storage-ext/pkg"
+	"github.com/josajunior81/terraform-provider-vcd-object-storage-ext/pkg/osetest"
+)
+
+// newTokenClient returns a client authenticating with the API token of the
+// emulator, which also plays the VCD.
+func newTokenClient(t *testing.T, server *osetest.Server, opts ...pkg.S3ClientOption) pkg.S3Client {
+	t.Helper()
+
+	policy := pkg.DefaultRetryPolicy()
+	policy.BaseDelay = 10 * time.Millisecond
+	policy.MaxDelay = 50 * time.Millisecond
+
+	opts = append([]pkg.S3ClientOption{pkg.WithRetryPolicy(policy)}, opts...)
+	client, err := pkg.NewS3Client(server.URL, "us-east-1", osetest.APIToken, "org", server.URL, opts...)
+	if err != nil {
+		t.Fatalf("NewS3Client: %v", err)
+	}
+	return client
+}
+
+// countRequests counts the requests sent to the S3 API of server.
+func countRequests(server *osetest.Server) *atomic.Int32 {
+	count := &atomic.Int32{}
+	server.Intercept = func(_ http.ResponseWriter, r *http.Request) bool {
+		if strings.HasPrefix(r.URL.Path, "/"+pkg.PATH+"/") {
+			count.Add(1)
+		}
+		return false
+	}
+	return count
+}
+
+func TestBearerToken(t *testing.T) {
+	ctx := context.Background()
+	server := osetest.NewServer()
+	defer server.Close()
+
+	client := newTokenClient(t, server)
+	if err := client.CreateBucket(ctx, "b1"); err != nil {
+		t.Fatalf("CreateBucket: %v", err)
+	}
+	if _, err := client.GetBucket(ctx, "b1"); err != nil {
+		t.Fatalf("GetBucket: %v", err)
+	}
+	if n := server.TokenExchanges(); n != 1 {
+		t.Errorf("API token exchanged %d times, want once", n)
+	}
+
+	_, err := pkg.NewS3Client(server.URL, "us-east-1", "wrong", "org", server.URL)
+	if !errors.Is(err, pkg.ErrAuthentication) {
+		t.Errorf("NewS3Client with a wrong API token = %v, want ErrAuthentication", err)
+	}
+}
+
+func TestBearerTokenRefreshedBeforeExpiry(t *testing.T) {
+	ctx := context.Background()
+	server := osetest.NewServer()
+	defer server.Close()
+
+	// A token lasting less than the refresh margin is renewed before every
+	// request instead of lapsing in flight.
+	server.TokenExpiresIn = 60
+	client := newTokenClient(t, server)
+	requests := countRequests(server)
+
+	if err := client.CreateBucket(ctx, "b1"); err != nil {
+		t.Fatalf("CreateBucket: %v", err)
+	}
+	if _, err := client.GetBucket(ctx, "b1"); err != nil {
+		t.Fatalf("GetBucket: %v", err)
+	}
+	if n := server.TokenExchanges(); n != 3 {
+		t.Errorf("API token exchanged %d times, want 3", n)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("server received %d requests, want 2 without any 401", n)
+	}
+}
+
+func TestBearerTokenReplayedAfter401(t *testing.T) {
+	ctx := context.Background()
+	server := osetest.NewServer()
+	defer server.Close()
+
+	client := newTokenClient(t, server)
+	requests := countRequests(server)
+
+	server.ExpireTokens()
+	if err := client.CreateBucket(ctx, "b1"); err != nil {
+		t.Fatalf("CreateBucket with an expired token: %v", err)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("server received %d requests, want the 401 and a single replay", n)
+	}
+	if n := server.TokenExchanges(); n != 2 {
+		t.Errorf("API token exchanged %d times, want 2", n)
+	}
+
+	if _, err := client.GetBucket(ctx, "b1"); err != nil {
+		t.Fatalf("GetBucket with the refreshed token: %v", err)
+	}
+	if n := server.TokenExchanges(); n != 2 {
+		t.Errorf("API token exchanged %d times after the refresh, want 2", n)
+	}
+}
+
+func TestBearerTokenReplayedOnce(t *testing.T) {
+	server := osetest.NewServer()
+	defer server.Close()
+
+	client := newTokenClient(t, server)
+
+	var requests atomic.Int32
+	server.Intercept = func(w http.ResponseWriter, r *http.Request) bool {
+		if !strings.HasPrefix(r.URL.Path, "/"+pkg.PATH+"/") {
+			return false
+		}
+		requests.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+		return true
+	}
+
+	_, err := client.GetBucketPolicy(context.Background(), "b1")
+	var apiErr *pkg.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("GetBucketPolicy = %v, want a 401 APIError", err)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("server received %d requests, want the 401 and a single replay", n)
+	}
+	if n := server.TokenExchanges(); n != 2 {
+		t.Errorf("API token exchanged %d times, want 2", n)
+	}
+}
+
+func TestBearerTokenReplaysBodies(t *testing.T) {
+	ctx := context.Background()
+	server := osetest.NewServer()
+	defer server.Close()
+
+	client := newTokenClient(t, server, pkg.WithMultipartConfig(pkg.MultipartConfig{
+		Threshold:   12 << 20,
+		PartSize:    5 << 20,
+		Concurrency: 1,
+	}))
+	if err := client.CreateBucket(ctx, "b1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Payloads built from strings.
+	server.ExpireTokens()
+	err := client.BucketTags(ctx, "b1", []pkg.Tag{{Key: "env", Value: "test"}})
+	if err != nil {
+		t.Fatalf("BucketTags with an expired token: %v", err)
+	}
+	if tags, err := client.GetBucketTags(ctx, "b1"); err != nil || len(tags) != 1 {
+		t.Errorf("GetBucketTags = %v, %v", tags, err)
+	}
+
+	// Objects streamed from the file in a single request.
+	small := []byte("hello object storage")
+	server.ExpireTokens()
+	if err := client.UploadObject(ctx, "b1", "small.txt", writeFile(t, small), true); err != nil {
+		t.Fatalf("UploadObject with an expired token: %v", err)
+	}
+	if data, _ := server.Object("b1", "small.txt"); !bytes.Equal(data, small) {
+		t.Errorf("stored content = %q, want %q", data, small)
+	}
+
+	// Parts of a multipart upload, with the token expiring between parts.
+	var expire sync.Once
+	server.Intercept = func(_ http.ResponseWriter, r *http.Request) bool {
+		if r.URL.Query().Get("partNumber") == "2" {
+			expire.Do(server.ExpireTokens)
+		}
+		return false
+	}
+	large := bytes.Repeat([]byte("0123456789abcdef"), (13<<20)/16)
+	if err := client.UploadObject(ctx, "b1", "large.bin", writeFile(t, large), true); err != nil {
+		t.Fatalf("UploadObject with the token expiring mid-upload: %v", err)
+	}
+	if data, _ := server.Object("b1", "large.bin"); !bytes.Equal(data, large) {
+		t.Error("stored content differs from the source")
+	}
+}
@@ -1,17 +1,75 @@
 package pkg
 
-import "net/http"
+import (
+	"context"
+	"crypto/tls"
+	"encoding/xml"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ObjectStorage is the set of Object Storage Extension operations used by the
+// provider resources. S3Client talks to a real OSE, MemoryStorage keeps
+// everything in memory for offline tests.
+type ObjectStorage interface {
+	ListBuckets(ctx context.Context) ([]string, error)
+	GetBucket(ctx context.Context, name string) (string, error)
+	CreateBucket(ctx context.Context, name string) error
+	BucketTags(ctx context.Context, bucket string, tags []Tag) error
+	GetBucketTags(ctx context.Context, bucket string) ([]Tag, error)
+	BucketAcls(ctx context.Context, bucket, cannedAcl string, acls []BucketAcl) error
+	GetBucketAcl(ctx context.Context, bucket string) (*AccessControlPolicy, error)
+	BucketCors(ctx context.Context, bucket string, rules []CORSRule) error
+	GetBucketCors(ctx context.Context, bucket string) ([]CORSRule, error)
+	BucketLifecycle(ctx context.Context, bucket string, lifecycle LifecycleConfiguration) error
+	GetBucketLifecycle(ctx context.Context, bucket string) ([]LifecycleRule, error)
+	BucketPolicy(ctx context.Context, bucket, policy string) error
+	GetBucketPolicy(ctx context.Context, bucket string) (string, error)
+	BucketEncryption(ctx context.Context, bucket string, encryption ServerSideEncryptionConfiguration) error
+	GetBucketEncryption(ctx context.Context, bucket string) ([]ServerSideEncryptionRule, error)
+	BucketLogging(ctx context.Context, bucket string, logging BucketLoggingStatus) error
+	GetBucketLogging(ctx context.Context, bucket string) (*BucketLoggingStatus, error)
+	EnsureLogDeliveryGrants(ctx context.Context, bucket string) error
+	UploadObject(ctx context.Context, bucket, key, source string, overwrite bool) error
+	HeadObject(ctx context.Context, bucket, key string) (*ObjectInfo, error)
+	DeleteObject(ctx context.Context, bucket, key string, allVersions bool) error
+	DeleteBucket(ctx context.Context, name string) error
+}
+
+var (
+	_ ObjectStorage = S3Client{}
+	_ ObjectStorage = (*MemoryStorage)(nil)
+)
 
 type S3Client struct {
-	client      *http.Client
-	s3Url       string
-	region      string
-	bearerToken string
-	path        string
+	client *http.Client
+	s3Url  string
+	region string
+	auth   authorizer
+	path   string
+	retry  RetryPolicy
+
+	multipart MultipartConfig
+	tlsConfig *tls.Config
+	protocol  protocol
 }
 
+// authorizer adds credentials to the requests sent by S3Client.
+type authorizer interface {
+	authorize(req *http.Request) error
+	// refresh renews the credentials req was sent with after a 401 and
+	// reports whether the request should be replayed.
+	refresh(req *http.Request) bool
+}
+
+var (
+	_ authorizer = (*bearerTokenSource)(nil)
+	_ authorizer = (*sigV4Signer)(nil)
+)
+
 type Bucket struct {
-	Name      string `json:"name"`
+	Name      string `json:"name" xml:"Name"`
 	Tenant    string `json:"tenant"`
 	S3Href    string `json:"s3Href"`
 	S3AltHref string `json:"s3AltHref"`
@@ -19,6 +77,234 @@ type Bucket struct {
 }
 
 type Owner struct {
-	Id          string `json:"id"`
-	DisplayName string `json:"displayName"`
+	Id          string `json:"id" xml:"ID"`
+	DisplayName string `json:"displayName" xml:"DisplayName"`
+}
+
+// ObjectInfo is the metadata of a stored object.
+type ObjectInfo struct {
+	// ETag is the entity tag without quotes. It is the MD5 of the content for
+	// objects uploaded in a single request and ends with -<parts> otherwise.
+	// Objects encrypted with SSE-KMS have an opaque ETag.
+	ETag         string
+	Size         int64
+	ContentType  string
+	LastModified time.Time
+	// ServerSideEncryption is the algorithm the object is encrypted with at
+	// rest, e.g. SSEAlgorithmKMS, or empty.
+	ServerSideEncryption string
+}
+
+// ETagIsMD5 reports whether the ETag of the object is the MD5 of its
+// content, which is not the case for objects uploaded in parts or encrypted
+// with SSE-KMS.
+func (o ObjectInfo) ETagIsMD5() bool {
+	return o.ETag != "" && !strings.Contains(o.ETag, "-") && !strings.HasPrefix(o.ServerSideEncryption, SSEAlgorithmKMS)
+}
+
+// The payloads below are shared by the OSE JSON API and the S3 XML API.
+
+type Tagging struct {
+	XMLName xml.Name `json:"-" xml:"Tagging"`
+	TagSets []TagSet `json:"tagSets" xml:"TagSet"`
+}
+
+type TagSet struct {
+	Tags []Tag `json:"tags" xml:"Tag"`
+}
+
+type Tag struct {
+	Key   string `json:"key" xml:"Key"`
+	Value string `json:"value" xml:"Value"`
+}
+
+type AccessControlPolicy struct {
+	XMLName xml.Name `json:"-" xml:"AccessControlPolicy"`
+	Owner   Owner    `json:"owner" xml:"Owner"`
+	Grants  []Grant  `json:"grants" xml:"AccessControlList>Grant"`
+}
+
+type Grant struct {
+	Grantee    Grantee `json:"grantee" xml:"Grantee"`
+	Permission string  `json:"permission" xml:"Permission"`
+}
+
+// BucketAcl grants Permission on a bucket to User, one of TENANT,
+// AUTHENTICATED, PUBLIC and SYSTEM-LOGGER.
+type BucketAcl struct {
+	User       string
+	Permission string
+}
+
+// Grantee is either a canonical user, identified by Id, or a group,
+// identified by Uri.
+type Grantee struct {
+	XMLNS string `json:"-" xml:"xmlns:xsi,attr,omitempty"`
+	Type  string `json:"-" xml:"xsi:type,attr,omitempty"`
+	Id    string `json:"id,omitempty" xml:"ID,omitempty"`
+	Uri   string `json:"uri,omitempty" xml:"URI,omitempty"`
+}
+
+func userGrantee(id string) Grantee {
+	return Grantee{XMLNS: xsiNamespace, Type: "CanonicalUser", Id: id}
+}
+
+func groupGrantee(uri string) Grantee {
+	return Grantee{XMLNS: xsiNamespace, Type: "Group", Uri: uri}
+}
+
+const xsiNamespace = "http://www.w3.org/2001/XMLSchema-instance"
+
+// UnmarshalXML reads a grantee of an S3 ACL. encoding/xml cannot match the
+// prefixed xsi:type attribute, so the type is taken from the attribute local
+// name, or from the identifier when it is missing. Without it the grantee
+// would be sent back untyped when the ACL is updated.
+func (g *Grantee) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var grantee struct {
+		Id  string `xml:"ID"`
+		Uri string `xml:"URI"`
+	}
+	if err := d.DecodeElement(&grantee, &start); err != nil {
+		return err
+	}
+
+	if grantee.Uri != "" {
+		*g = groupGrantee(grantee.Uri)
+	} else {
+		*g = userGrantee(grantee.Id)
+	}
+	for _, attr := range start.Attr {
+		if attr.Name.Local == "type" && attr.Value != "" {
+			g.Type = attr.Value
+		}
+	}
+
+	return nil
+}
+
+type CORSConfiguration struct {
+	XMLName xml.Name   `json:"-" xml:"CORSConfiguration"`
+	Rules   []CORSRule `json:"corsRules" xml:"CORSRule"`
+}
+
+type CORSRule struct {
+	AllowedHeaders []string `json:"allowedHeaders,omitempty" xml:"AllowedHeader"`
+	AllowedMethods []string `json:"allowedMethods" xml:"AllowedMethod"`
+	AllowedOrigins []string `json:"allowedOrigins" xml:"AllowedOrigin"`
+	ExposeHeaders  []string `json:"exposeHeaders,omitempty" xml:"ExposeHeader"`
+	MaxAgeSeconds  int      `json:"maxAgeSeconds,omitempty" xml:"MaxAgeSeconds,omitempty"`
+}
+
+// Lifecycle rule states.
+const (
+	LifecycleEnabled  = "Enabled"
+	LifecycleDisabled = "Disabled"
+)
+
+type LifecycleConfiguration struct {
+	XMLName xml.Name        `json:"-" xml:"LifecycleConfiguration"`
+	Rules   []LifecycleRule `json:"rules" xml:"Rule"`
+}
+
+type LifecycleRule struct {
+	Id                             string                          `json:"id" xml:"ID"`
+	Status                         string                          `json:"status" xml:"Status"`
+	Filter                         *LifecycleFilter                `json:"filter,omitempty" xml:"Filter,omitempty"`
+	Expiration                     *LifecycleExpiration            `json:"expiration,omitempty" xml:"Expiration,omitempty"`
+	NoncurrentVersionExpiration    *NoncurrentVersionExpiration    `json:"noncurrentVersionExpiration,omitempty" xml:"NoncurrentVersionExpiration,omitempty"`
+	AbortIncompleteMultipartUpload *AbortIncompleteMultipartUpload `json:"abortIncompleteMultipartUpload,omitempty" xml:"AbortIncompleteMultipartUpload,omitempty"`
+}
+
+// LifecycleFilter selects the objects a rule applies to. A prefix or a single
+// tag is set directly, a combination of them goes into And. An empty filter
+// selects every object of the bucket.
+type LifecycleFilter struct {
+	Prefix string                      `json:"prefix,omitempty" xml:"Prefix,omitempty"`
+	Tag    *Tag                        `json:"tag,omitempty" xml:"Tag,omitempty"`
+	And    *LifecycleFilterAndOperator `json:"and,omitempty" xml:"And,omitempty"`
+}
+
+type LifecycleFilterAndOperator struct {
+	Prefix string `json:"prefix,omitempty" xml:"Prefix,omitempty"`
+	Tags   []Tag  `json:"tags,omitempty" xml:"Tag"`
+}
+
+// NewLifecycleFilter returns the filter selecting the objects under prefix
+// carrying every tag in tags.
+func NewLifecycleFilter(prefix string, tags []Tag) *LifecycleFilter {
+	switch {
+	case len(tags) == 0:
+		return &LifecycleFilter{Prefix: prefix}
+	case len(tags) == 1 && prefix == "":
+		return &LifecycleFilter{Tag: &tags[0]}
+	default:
+		return &LifecycleFilter{And: &LifecycleFilterAndOperator{Prefix: prefix, Tags: tags}}
+	}
+}
+
+// PrefixAndTags returns the prefix and the tags selected by the filter,
+// whichever form it was given in.
+func (f *LifecycleFilter) PrefixAndTags() (string, []Tag) {
+	switch {
+	case f == nil:
+		return "", nil
+	case f.And != nil:
+		return f.And.Prefix, f.And.Tags
+	case f.Tag != nil:
+		return f.Prefix, []Tag{*f.Tag}
+	default:
+		return f.Prefix, nil
+	}
+}
+
+// LifecycleExpiration expires current object versions Days after their
+// creation or at Date, midnight UTC in ISO 8601 format.
+type LifecycleExpiration struct {
+	Days int    `json:"days,omitempty" xml:"Days,omitempty"`
+	Date string `json:"date,omitempty" xml:"Date,omitempty"`
+}
+
+type NoncurrentVersionExpiration struct {
+	NoncurrentDays int `json:"noncurrentDays" xml:"NoncurrentDays"`
+}
+
+type AbortIncompleteMultipartUpload struct {
+	DaysAfterInitiation int `json:"daysAfterInitiation" xml:"DaysAfterInitiation"`
+}
+
+// Server-side encryption algorithms of a bucket default encryption. SSE-C
+// keys are given with every request and cannot be a bucket default.
+const (
+	SSEAlgorithmAES256 = "AES256"
+	SSEAlgorithmKMS    = "aws:kms"
+)
+
+type ServerSideEncryptionConfiguration struct {
+	XMLName xml.Name                   `json:"-" xml:"ServerSideEncryptionConfiguration"`
+	Rules   []ServerSideEncryptionRule `json:"rules" xml:"Rule"`
+}
+
+type ServerSideEncryptionRule struct {
+	ApplyServerSideEncryptionByDefault ServerSideEncryptionByDefault `json:"applyServerSideEncryptionByDefault" xml:"ApplyServerSideEncryptionByDefault"`
+}
+
+// ServerSideEncryptionByDefault is the encryption of objects stored without
+// encryption headers. KMSMasterKeyID is only used with SSEAlgorithmKMS.
+type ServerSideEncryptionByDefault struct {
+	SSEAlgorithm   string `json:"sseAlgorithm" xml:"SSEAlgorithm"`
+	KMSMasterKeyID string `json:"kmsMasterKeyId,omitempty" xml:"KMSMasterKeyID,omitempty"`
+}
+
+// BucketLoggingStatus enables server access logging of a bucket when
+// LoggingEnabled is set, and disables it otherwise.
+type BucketLoggingStatus struct {
+	XMLName        xml.Name        `json:"-" xml:"BucketLoggingStatus"`
+	LoggingEnabled *LoggingEnabled `json:"loggingEnabled,omitempty" xml:"LoggingEnabled,omitempty"`
+}
+
+// LoggingEnabled is where the access logs are delivered. The LogDelivery
+// group needs the WRITE and READ_ACP permissions on TargetBucket.
+type LoggingEnabled struct {
+	TargetBucket string `json:"targetBucket" xml:"TargetBucket"`
+	TargetPrefix string `json:"targetPrefix" xml:"TargetPrefix"`
 }